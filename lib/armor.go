@@ -0,0 +1,76 @@
+package gorl
+
+type Armor interface {
+	Wearable
+	SetResistance(DamageType, int)
+}
+
+func init() {
+	RegisterFeatureType("armor", &armor{}, armorRecord{},
+		func(f Feature, s *Saver) (interface{}, error) {
+			a := f.(*armor)
+			return armorRecord{a.item.record(), a.slot, a.defense, a.resistances}, nil
+		},
+		func(record interface{}, l *Loader) (Feature, error) {
+			r := record.(armorRecord)
+			a := &armor{}
+			a.item.restore(r.Item)
+			a.slot = r.Slot
+			a.defense = r.Defense
+			a.resistances = r.Resistances
+			if a.resistances == nil {
+				a.resistances = make(Resistances)
+			}
+			return a, nil
+		},
+	)
+}
+
+type armor struct {
+	item
+	slot        string
+	defense     uint
+	resistances Resistances
+}
+
+// NewArmor creates and returns armor worn on slot, which takes defense off
+// the damage of every hit its wearer takes.
+func NewArmor(name string, char rune, weight int, slot string, defense uint) Armor {
+	a := armor{
+		item{
+			*NewFeature(name, char).(*feature),
+			weight,
+		},
+		slot,
+		defense,
+		make(Resistances),
+	}
+	a.flags |= FlagCrossable
+	return &a
+}
+
+func (a *armor) Slot() string {
+	return a.slot
+}
+
+func (a *armor) Defense() uint {
+	return a.defense
+}
+
+func (a *armor) Resistances() Resistances {
+	return a.resistances
+}
+
+// SetResistance sets how much of damageType the armor keeps off its wearer,
+// as a percentage
+func (a *armor) SetResistance(damageType DamageType, percent int) {
+	a.resistances[damageType] = percent
+}
+
+// armorRecord is the saved form of armor
+type armorRecord struct {
+	Item        itemRecord
+	Slot        string
+	Defense     uint
+	Resistances Resistances
+}
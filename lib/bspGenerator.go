@@ -0,0 +1,172 @@
+package gorl
+
+import (
+	"log"
+	"math/rand"
+)
+
+// A BSPGenerator makes levels of rooms joined by corridors. It splits the
+// level in two, then splits each half in two, and so on until the parts are
+// too small to split; then it puts a room in each part, and joins each pair
+// of halves back up with a corridor.
+type BSPGenerator struct {
+	// MinLeafSize is the smallest a part of the level can be split down to
+	MinLeafSize int
+	// MinRoomSize is the smallest a room's floor can be
+	MinRoomSize int
+}
+
+// DefaultBSPGenerator makes rooms anywhere from 4 to 18 tiles across
+var DefaultBSPGenerator = BSPGenerator{MinLeafSize: 10, MinRoomSize: 4}
+
+func (g BSPGenerator) Generate(log *log.Logger, dice *rand.Rand, width, height, depth int) *Dungeon {
+	if g.MinRoomSize < 1 || g.MinLeafSize < g.MinRoomSize+2 {
+		log.Panicf("Bad BSP generator: %+v", g)
+	}
+	if width-2 < g.MinRoomSize+2 || height-2 < g.MinRoomSize+2 {
+		log.Panicf("Level is too small for a room: %dx%d", width, height)
+	}
+	d := newSolidDungeon(log, width, height, depth)
+	var rooms []Rectangle
+	g.split(d, Rectangle{Vector{1, 1}, Vector{width - 2, height - 2}}, dice, &rooms)
+	d.origin = rectangleCenter(rooms[0])
+
+	// Where rooms are only a wall apart, there's a gap in each wall, but
+	// only one door is needed
+	var portals, deadEnds []Vector
+	for _, room := range rooms {
+		gaps := doorways(d, room)
+		for _, gap := range gaps {
+			if !nextToAny(gap, portals) {
+				portals = append(portals, gap)
+			}
+		}
+		if len(gaps) == 1 {
+			deadEnds = append(deadEnds, gaps[0])
+		}
+	}
+	// Every other doorway is on the only way between two parts of the level,
+	// so only the doors into rooms with no other way in are ever locked
+	var lockable []Vector
+	for _, portal := range portals {
+		if nextToAny(portal, deadEnds) {
+			lockable = append(lockable, portal)
+		}
+	}
+	for _, deadEnd := range deadEnds {
+		lockable = append(lockable, deadEnd)
+	}
+	log.Printf("Room portals: %s", portals)
+	if err := d.connect(dice); err != nil {
+		log.Panic(err)
+	}
+	d.placeDoors(portals, lockable, dice)
+	d.placeStairs(dice)
+	return d
+}
+
+// MinLevelSize leaves room for one of the smallest rooms, with its walls,
+// inside the solid edge of the level
+func (g BSPGenerator) MinLevelSize() (width, height int) {
+	return g.MinRoomSize + 4, g.MinRoomSize + 4
+}
+
+// split digs out rooms in area, adding them to rooms, and returns one of them
+// for joining up to the rest of the level.
+func (g BSPGenerator) split(d *Dungeon, area Rectangle, dice *rand.Rand, rooms *[]Rectangle) Rectangle {
+	canSplitX, canSplitY := area.Width() >= 2*g.MinLeafSize, area.Height() >= 2*g.MinLeafSize
+	if !canSplitX && !canSplitY {
+		return g.digRoom(d, area, dice, rooms)
+	}
+	// Split across the longer side, or either if they're about the same
+	splitX := canSplitX
+	if canSplitX && canSplitY {
+		switch {
+		case area.Width()*4 > area.Height()*5:
+			splitX = true
+		case area.Height()*4 > area.Width()*5:
+			splitX = false
+		default:
+			splitX = dice.Intn(2) == 0
+		}
+	}
+	var a, b Rectangle
+	if splitX {
+		at := g.MinLeafSize + dice.Intn(area.Width()-2*g.MinLeafSize+1)
+		a = Rectangle{area.topLeft, Vector{at, area.Height()}}
+		b = Rectangle{area.topLeft.Add(Vector{at, 0}), Vector{area.Width() - at, area.Height()}}
+	} else {
+		at := g.MinLeafSize + dice.Intn(area.Height()-2*g.MinLeafSize+1)
+		a = Rectangle{area.topLeft, Vector{area.Width(), at}}
+		b = Rectangle{area.topLeft.Add(Vector{0, at}), Vector{area.Width(), area.Height() - at}}
+	}
+	roomA, roomB := g.split(d, a, dice, rooms), g.split(d, b, dice, rooms)
+	digCorridor(d, rectangleCenter(roomA), rectangleCenter(roomB), dice)
+	if dice.Intn(2) == 0 {
+		return roomA
+	}
+	return roomB
+}
+
+// digRoom digs out a room somewhere in area, leaving room for its walls.
+func (g BSPGenerator) digRoom(d *Dungeon, area Rectangle, dice *rand.Rand, rooms *[]Rectangle) Rectangle {
+	size := Vector{
+		g.MinRoomSize + dice.Intn(area.Width()-g.MinRoomSize-1),
+		g.MinRoomSize + dice.Intn(area.Height()-g.MinRoomSize-1),
+	}
+	topLeft := area.topLeft.Add(Vector{
+		1 + dice.Intn(area.Width()-size.x-1),
+		1 + dice.Intn(area.Height()-size.y-1),
+	})
+	room := Rectangle{topLeft, size}
+	for y := topLeft.y; y < room.BottomRight().y; y++ {
+		for x := topLeft.x; x < room.BottomRight().x; x++ {
+			d.tiles[y][x] = newFloor()
+		}
+	}
+	*rooms = append(*rooms, room)
+	d.rooms = append(d.rooms, Room{Rectangle{topLeft.Sub(Vector{1, 1}), size.Add(Vector{2, 2})}, ""})
+	return room
+}
+
+// rectangleCenter returns the middle of r
+func rectangleCenter(r Rectangle) Vector {
+	return r.topLeft.Add(Vector{r.Width() / 2, r.Height() / 2})
+}
+
+// nextToAny returns true if loc is a step across or down from any of locs
+func nextToAny(loc Vector, locs []Vector) bool {
+	for _, other := range locs {
+		if offset := loc.Sub(other); IntAbs(offset.x)+IntAbs(offset.y) == 1 {
+			return true
+		}
+	}
+	return false
+}
+
+// doorways returns the gaps dug through the walls around room, which have
+// wall on either side and floor in front and behind.
+func doorways(d *Dungeon, room Rectangle) []Vector {
+	var gaps []Vector
+	isWall := func(loc Vector) bool {
+		return !d.Tile(loc).Crossable()
+	}
+	top, bottom := room.topLeft.y-1, room.BottomRight().y
+	left, right := room.topLeft.x-1, room.BottomRight().x
+	for y := top; y <= bottom; y++ {
+		for x := left; x <= right; x++ {
+			loc := Vector{x, y}
+			if (y != top && y != bottom && x != left && x != right) || isWall(loc) {
+				continue
+			}
+			acrossWall := isWall(loc.Add(MoveWest)) && isWall(loc.Add(MoveEast)) &&
+				!isWall(loc.Add(MoveNorth)) && !isWall(loc.Add(MoveSouth))
+			downWall := isWall(loc.Add(MoveNorth)) && isWall(loc.Add(MoveSouth)) &&
+				!isWall(loc.Add(MoveWest)) && !isWall(loc.Add(MoveEast))
+			if acrossWall || downWall {
+				gaps = append(gaps, loc)
+			}
+		}
+	}
+	return gaps
+}
@@ -0,0 +1,87 @@
+package gorl
+
+import (
+	"log"
+	"math/rand"
+)
+
+// A CaveGenerator makes levels of winding caves with a cellular automaton. It
+// starts with rock scattered at random, then smooths it out a few times,
+// turning each tile to rock if most of the tiles around it are rock, and to
+// floor if they aren't.
+type CaveGenerator struct {
+	// FillChance is the chance of each tile starting out as rock
+	FillChance float64
+	// Steps is how many times the rock is smoothed out
+	Steps int
+}
+
+// DefaultCaveGenerator makes roomy caves with a few pillars
+var DefaultCaveGenerator = CaveGenerator{FillChance: 0.45, Steps: 5}
+
+func (g CaveGenerator) Generate(log *log.Logger, dice *rand.Rand, width, height, depth int) *Dungeon {
+	if g.FillChance < 0 || g.FillChance > 1 || g.Steps < 0 {
+		log.Panicf("Bad cave generator: %+v", g)
+	}
+	rock := make([][]bool, height)
+	for y := range rock {
+		rock[y] = make([]bool, width)
+		for x := range rock[y] {
+			rock[y][x] = onEdge(x, y, width, height) || dice.Float64() < g.FillChance
+		}
+	}
+	for i := 0; i < g.Steps; i++ {
+		rock = smoothCave(rock)
+	}
+
+	d := newSolidDungeon(log, width, height, depth)
+	for y := range rock {
+		for x := range rock[y] {
+			if !rock[y][x] {
+				d.tiles[y][x] = newFloor()
+			}
+		}
+	}
+	if err := d.connect(dice); err != nil {
+		log.Panic(err)
+	}
+	d.placeStairs(dice)
+	return d
+}
+
+// MinLevelSize is big enough that the caves never come out solid rock
+func (g CaveGenerator) MinLevelSize() (width, height int) {
+	return 16, 16
+}
+
+// onEdge returns true if (x, y) is on the edge of a width by height level
+func onEdge(x, y, width, height int) bool {
+	return x == 0 || y == 0 || x == width-1 || y == height-1
+}
+
+// smoothCave returns rock after one step of the cave automaton: a tile is
+// rock if at least five of the nine tiles around and including it are. The
+// edge of the level is always rock.
+func smoothCave(rock [][]bool) [][]bool {
+	height, width := len(rock), len(rock[0])
+	next := make([][]bool, height)
+	for y := range rock {
+		next[y] = make([]bool, width)
+		for x := range rock[y] {
+			if onEdge(x, y, width, height) {
+				next[y][x] = true
+				continue
+			}
+			walls := 0
+			for dy := -1; dy <= 1; dy++ {
+				for dx := -1; dx <= 1; dx++ {
+					if rock[y+dy][x+dx] {
+						walls++
+					}
+				}
+			}
+			next[y][x] = walls >= 5
+		}
+	}
+	return next
+}
@@ -1,13 +1,26 @@
 package gorl
 
 import (
+	"flag"
+	"fmt"
+	"io/ioutil"
 	"log"
-	"math/rand"
 	"os"
+	"strings"
 	"time"
 )
 
-const logFilePath = "gorl.log"
+const defaultLogFilePath = "gorl.log"
+
+// defaultSavePath is where the game is saved on quitting, unless told
+// otherwise
+const defaultSavePath = "gorl.sav"
+
+// Size of the screen printed by -headless
+const (
+	headlessWidth  = 80
+	headlessHeight = 24
+)
 
 type GorlCLI interface {
 	Run()
@@ -15,15 +28,82 @@ type GorlCLI interface {
 }
 
 type gorlCLI struct {
-	logFile *os.File
-	log     *log.Logger
-	game    *Game
+	logFile    *os.File
+	log        *log.Logger
+	game       *Game
+	savePath   string
+	replayFile *os.File
 }
 
 func NewCLI(args []string) GorlCLI {
 	cli := gorlCLI{}
+	flags := flag.NewFlagSet("gorl", flag.ExitOnError)
+	seed := flags.Int64("seed", 0, "seed for a new game; random if not given")
+	logFilePath := flags.String("log", defaultLogFilePath, "file to log to")
+	width := flags.Int("width", DefaultLevelWidth, "width of each level in a new game")
+	height := flags.Int("height", DefaultLevelHeight, "height of each level in a new game")
+	load := flags.Bool("load", false, "continue the game in the save file")
+	flags.StringVar(&cli.savePath, "save", defaultSavePath, "save file, written on quitting")
+	recordPath := flags.String("record", "", "record the game to this replay file")
+	replayPath := flags.String("replay", "", "watch the game recorded in this replay file")
+	replayDelay := flags.Duration("replay-delay", 100*time.Millisecond, "time between moves when watching a replay")
+	contentDir := flags.String("data", "", "directory holding the content files, if not the data directory next to the game")
+	generatorNames := flags.String("generator", "rooms", "level generator, or a comma separated list of one for each level: "+strings.Join(GeneratorNames(), ", "))
+	headless := flags.Bool("headless", false, "play back the -replay without a terminal, printing where it ends up")
+	mapPath := flags.String("map", "", "start a new game on the level in this map file, JSON if it ends in .json and ASCII otherwise")
+	exportPath := flags.String("export", "", "write the first level of a new game to this map file, JSON if it ends in .json and ASCII otherwise, and quit")
+	flags.Parse(args)
+
+	if *replayPath != "" && (*load || *recordPath != "") {
+		fmt.Fprintln(os.Stderr, "-replay can't be used with -load or -record")
+		os.Exit(2)
+	}
+	if *headless && *replayPath == "" {
+		fmt.Fprintln(os.Stderr, "-headless can only be used with -replay")
+		os.Exit(2)
+	}
+	if *load && *recordPath != "" {
+		fmt.Fprintln(os.Stderr, "-record can only record new games, not -load'ed ones")
+		os.Exit(2)
+	}
+	if (*mapPath != "" || *exportPath != "") && (*load || *replayPath != "") {
+		fmt.Fprintln(os.Stderr, "-map and -export are for new games, not -load or -replay")
+		os.Exit(2)
+	}
+
+	generator, err := ParseGenerators(*generatorNames)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+	// Loaded and replayed games are the size they were saved or recorded at
+	minWidth, minHeight := MinLevelSize(generator)
+	if !*load && *replayPath == "" && (*width < minWidth || *height < minHeight) {
+		fmt.Fprintf(os.Stderr, "-width and -height must be at least %d and %d for -generator %s\n", minWidth, minHeight, *generatorNames)
+		os.Exit(2)
+	}
+
+	if *contentDir == "" {
+		*contentDir = FindContentDir()
+	}
+	content, err := LoadContentDir(*contentDir)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Bad content in %s: %s\n", *contentDir, err)
+		os.Exit(1)
+	}
+
+	seeded := false
+	flags.Visit(func(f *flag.Flag) {
+		if f.Name == "seed" {
+			seeded = true
+		}
+	})
+	if !seeded {
+		*seed = time.Now().UnixNano()
+	}
+
 	logFile, err := os.OpenFile(
-		logFilePath,
+		*logFilePath,
 		os.O_RDWR|os.O_APPEND|os.O_CREATE,
 		0666,
 	)
@@ -33,21 +113,122 @@ func NewCLI(args []string) GorlCLI {
 	cli.logFile = logFile
 	cli.log = log.New(logFile, "gorl: ", log.Ldate|log.Ltime|log.Lshortfile)
 	cli.log.Println("Starting gorl")
-	seed := time.Now().UnixNano()
-	dice := rand.New(rand.NewSource(seed))
-	game, err := NewGame(cli.log, dice)
+	var (
+		game   *Game
+		replay *Replay
+	)
+	config := DefaultGameConfig(*seed, content)
+	config.Width = *width
+	config.Height = *height
+	levels := Levels{Generators: *generatorNames}
+	if *mapPath != "" {
+		levels.MapPath = *mapPath
+		if levels.Map, err = ioutil.ReadFile(*mapPath); err != nil {
+			fmt.Fprintf(os.Stderr, "Bad map: %s\n", err)
+			os.Exit(1)
+		}
+	}
+	if err := levels.Configure(&config); err != nil {
+		fmt.Fprintf(os.Stderr, "Bad map: %s\n", err)
+		os.Exit(1)
+	}
+	if *exportPath != "" {
+		cli.log.Printf("Exporting the first level with seed %d to %s", *seed, *exportPath)
+		levelMap := newGame(cli.log, config).dungeonAt(0).LevelMap(content)
+		if err := levelMap.WriteFile(*exportPath); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		os.Exit(0)
+	}
+	if *load {
+		cli.log.Printf("Loading game from %s", cli.savePath)
+		if game, err = LoadGameFile(cli.log, cli.savePath, config); err != nil {
+			fmt.Fprintf(os.Stderr, "Couldn't load %s: %s\n", cli.savePath, err)
+			os.Exit(1)
+		}
+	} else if *replayPath != "" {
+		cli.log.Printf("Replaying %s", *replayPath)
+		if cli.replayFile, err = os.Open(*replayPath); err == nil {
+			replay, err = OpenReplay(cli.replayFile)
+		}
+		if err == nil {
+			if *headless {
+				config.NewUI = NewHeadlessUI(headlessWidth, headlessHeight, replay)
+			}
+			game, err = replay.NewGame(cli.log, config)
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Couldn't replay %s: %s\n", *replayPath, err)
+			os.Exit(1)
+		}
+	} else {
+		cli.log.Printf("New %dx%d game with seed %d", *width, *height, *seed)
+		game, err = NewGame(cli.log, config)
+	}
 	if err != nil {
 		cli.log.Panic(err)
 	}
+	cli.log.Printf("Seed: %d", game.Seed())
+
+	if replay != nil && !*headless {
+		game.SetUI(NewReplayUI(game.UI(), game, replay, *replayDelay))
+	} else if *recordPath != "" {
+		cli.log.Printf("Recording to %s", *recordPath)
+		recordFile, err := os.Create(*recordPath)
+		if err != nil {
+			cli.log.Panic(err)
+		}
+		ui, err := NewRecordingUI(game.UI(), game, recordFile)
+		if err != nil {
+			cli.log.Panic(err)
+		}
+		game.SetUI(ui)
+	}
 	cli.game = game
 	return &cli
 }
 
 func (cli *gorlCLI) Run() {
 	cli.game.Run()
+	if headless, ok := cli.game.UI().(HeadlessUI); ok {
+		cli.printHeadless(headless)
+	}
+	if cli.replayFile == nil {
+		cli.save()
+	}
+}
+
+// printHeadless prints what a HeadlessUI last showed to stdout.
+func (cli *gorlCLI) printHeadless(ui HeadlessUI) {
+	for _, row := range ui.Screen() {
+		fmt.Println(strings.TrimRight(row, " "))
+	}
+	for _, message := range ui.Messages() {
+		fmt.Println(message)
+	}
+}
+
+// save writes the game to the save file, unless the Player has died, in which
+// case any old save is removed.
+func (cli *gorlCLI) save() {
+	if cli.game.Player().Dead() {
+		cli.log.Printf("Player died, removing %s", cli.savePath)
+		if err := os.Remove(cli.savePath); err != nil && !os.IsNotExist(err) {
+			cli.log.Println(err)
+		}
+		return
+	}
+	cli.log.Printf("Saving game to %s", cli.savePath)
+	if err := cli.game.SaveFile(cli.savePath); err != nil {
+		cli.log.Printf("Couldn't save game: %s", err)
+	}
 }
 
 func (cli *gorlCLI) Close() {
+	if cli.replayFile != nil {
+		cli.replayFile.Close()
+	}
 	cli.logFile.Sync()
 	cli.logFile.Close()
 	cli.game.Close()
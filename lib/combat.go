@@ -1,12 +1,21 @@
 package gorl
 
+import (
+	"math/rand"
+)
+
 type Attacker interface {
-	AttackStrength() uint
-	Attack(Defender) (uint, bool)
+	Damage() []DamageRoll
+	Accuracy() int
+	OnHit() []Effect
+	Attack(Defender, *rand.Rand) (AttackResult, bool)
 }
 
 type Defender interface {
-	AttackedFor(uint) uint
+	Evasion() int
+	Resistance(DamageType) int
+	AttackedFor(DamagePacket) uint
+	AddEffect(Effect)
 	Dead() bool
 }
 
@@ -20,5 +29,72 @@ type Wielder interface {
 
 type Wieldable interface {
 	Item
-	AttackStrength() uint
+	Damage() []DamageRoll
+	Accuracy() int
+	OnHit() []Effect
+	// Range is how far the weapon can be fired, or 0 if it can't be
+	Range() int
+}
+
+type Wearer interface {
+	WearSlots() []string
+	Wearing() []Wearable
+	Defense() uint
+
+	Equip(Wearable) bool
+	Unequip(uint) bool
+}
+
+type Wearable interface {
+	Item
+	Slot() string
+	Defense() uint
+	Resistances() Resistances
+}
+
+// An attack hits if ToHitDie plus the Attacker's Accuracy is at least
+// ToHitTarget plus the Defender's Evasion. Rolling the highest number on the
+// die always hits, critically; rolling 1 is always a critical miss.
+const (
+	ToHitDie    = 20
+	ToHitTarget = 8
+)
+
+// AttackResult is the outcome of one attack.
+type AttackResult struct {
+	Hit bool
+	// Critical hits do double damage. A critical miss is a fumble.
+	Critical bool
+	// Damage is how much damage the Defender took, after armor and
+	// resistances
+	Damage uint
+	// Effects are the Attacker's OnHit effects, put on the Defender if the
+	// hit did any damage
+	Effects []Effect
+}
+
+// resolveAttack rolls for a attacking d, and deals any damage.
+func resolveAttack(a Attacker, d Defender, dice *rand.Rand) AttackResult {
+	var result AttackResult
+	toHit := dice.Intn(ToHitDie) + 1
+	switch {
+	case toHit == ToHitDie:
+		result.Hit, result.Critical = true, true
+	case toHit == 1:
+		result.Critical = true
+	default:
+		result.Hit = toHit+a.Accuracy() >= ToHitTarget+d.Evasion()
+	}
+	if !result.Hit {
+		return result
+	}
+
+	result.Damage = d.AttackedFor(rollDamage(a.Damage(), dice, result.Critical))
+	if result.Damage > 0 && !d.Dead() {
+		for _, effect := range a.OnHit() {
+			d.AddEffect(effect)
+			result.Effects = append(result.Effects, effect)
+		}
+	}
+	return result
 }
@@ -0,0 +1,87 @@
+package gorl
+
+import (
+	"io/ioutil"
+	"log"
+	"math/rand"
+	"testing"
+
+	"github.com/RWJMurphy/gorl/lib/roll"
+)
+
+func TestResolveAttack(t *testing.T) {
+	logger := log.New(ioutil.Discard, "", 0)
+	dice := rand.New(rand.NewSource(1))
+	attacker := NewMob("attacker", 'a', logger, nil)
+	attacker.SetBaseDamage([]DamageRoll{{DamageBlunt, roll.MustParse("1d4")}})
+	attacker.SetAccuracy(ToHitTarget)
+
+	var hits, crits, fumbles int
+	for i := 0; i < 1000; i++ {
+		defender := NewMob("defender", 'd', logger, nil)
+		defender.SetCorpse(nil)
+		defender.SetMaxHealth(100)
+		result := resolveAttack(attacker, defender, dice)
+		switch {
+		case result.Hit && result.Critical:
+			crits++
+			if result.Damage < 2 || result.Damage > 8 {
+				t.Errorf("Critical hit did %d damage, want 2 to 8", result.Damage)
+			}
+		case result.Hit:
+			hits++
+			if result.Damage < 1 || result.Damage > 4 {
+				t.Errorf("Hit did %d damage, want 1 to 4", result.Damage)
+			}
+		case result.Critical:
+			fumbles++
+		default:
+			t.Errorf("Missed with accuracy %d: %+v", attacker.Accuracy(), result)
+		}
+		if want := 100 - result.Damage; defender.Health() != want {
+			t.Errorf("Defender has %d health after taking %d damage", defender.Health(), result.Damage)
+		}
+	}
+	if crits == 0 || fumbles == 0 || hits < 800 {
+		t.Errorf("%d hits, %d crits and %d fumbles in 1000 attacks", hits, crits, fumbles)
+	}
+
+	attacker.SetAccuracy(-100)
+	for i := 0; i < 100; i++ {
+		if result := resolveAttack(attacker, NewMob("defender", 'd', logger, nil), dice); result.Hit && !result.Critical {
+			t.Fatalf("Hit with accuracy -100: %+v", result)
+		}
+	}
+}
+
+func TestAttackedForResistances(t *testing.T) {
+	logger := log.New(ioutil.Discard, "", 0)
+	troll := NewMob("ice troll", 'T', logger, nil)
+	troll.SetMaxHealth(100)
+	troll.SetResistance(DamageCold, 100)
+	troll.SetResistance(DamageFire, -100)
+	troll.SetResistance(DamagePoison, 50)
+	hide := NewArmor("hide", '[', 10, "body", 2)
+	hide.SetResistance(DamagePoison, 50)
+	troll.AddToInventory(hide)
+	troll.Equip(hide)
+
+	tests := []struct {
+		packet DamagePacket
+		want   uint
+	}{
+		{DamagePacket{{DamageSlashing, 5}}, 3},
+		{DamagePacket{{DamageSlashing, 1}}, 1},
+		{DamagePacket{{DamageCold, 10}}, 0},
+		{DamagePacket{{DamageFire, 5}}, 10},
+		{DamagePacket{{DamagePoison, 10}}, 0},
+		{DamagePacket{{DamageSlashing, 1}, {DamageBlunt, 3}, {DamageFire, 1}}, 4},
+		{DamagePacket{{DamageCold, 10}, {DamageSlashing, 2}}, 1},
+	}
+	for _, test := range tests {
+		health := troll.Health()
+		if got := troll.AttackedFor(test.packet); got != test.want || troll.Health() != health-got {
+			t.Errorf("AttackedFor(%v) = %d, want %d", test.packet, got, test.want)
+		}
+	}
+}
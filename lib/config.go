@@ -0,0 +1,165 @@
+package gorl
+
+import (
+	"bytes"
+	"fmt"
+	"log"
+	"math/rand"
+)
+
+// A Generator makes the empty level at depth, width by height, rolling dice
+// for anything random. Anything else it needs to know is up to the Generator.
+type Generator interface {
+	Generate(log *log.Logger, dice *rand.Rand, width, height, depth int) *Dungeon
+}
+
+// A SizedGenerator is a Generator that can't make levels smaller than its
+// MinLevelSize.
+type SizedGenerator interface {
+	Generator
+	MinLevelSize() (width, height int)
+}
+
+// MinLevelSize returns the smallest level generator can make: its
+// MinLevelSize if it's a SizedGenerator, or 1 by 1 if it isn't.
+func MinLevelSize(generator Generator) (width, height int) {
+	if sized, ok := generator.(SizedGenerator); ok {
+		return sized.MinLevelSize()
+	}
+	return 1, 1
+}
+
+// A LevelGenerator is a function that makes levels, as a Generator does.
+type LevelGenerator func(log *log.Logger, dice *rand.Rand, width, height, depth int) *Dungeon
+
+// Generate calls g
+func (g LevelGenerator) Generate(log *log.Logger, dice *rand.Rand, width, height, depth int) *Dungeon {
+	return g(log, dice, width, height, depth)
+}
+
+// A Populator fills a freshly generated level with Mobs and Items.
+type Populator func(game *Game, dungeon *Dungeon)
+
+// A StartingKit gives the Player whatever they start a new Game with.
+type StartingKit func(game *Game, player Player)
+
+// GameConfig is everything needed to set up a new Game. Change any part of
+// DefaultGameConfig to make a variant: an arena, a tutorial, a benchmark map.
+//
+// The same GameConfig and the same player actions always make the same Game.
+type GameConfig struct {
+	// Seed seeds the Game's dice
+	Seed int64
+	// Width and Height are the size of every level
+	Width, Height int
+	// Generator makes each level, the first time anybody goes there. Use
+	// LevelGenerators to make each level differently.
+	Generator Generator
+	// Populate fills each level after it's generated
+	Populate Populator
+	// StartingKit equips the Player
+	StartingKit StartingKit
+	// NewUI makes the UI the Game is played through
+	NewUI UIFactory
+	// Content is the monsters, items and spawn tables Populate and
+	// StartingKit make things from
+	Content *Content
+	// Levels is how Generator and Populate were set up, if Levels.Configure
+	// set them up, so that saves and replays can set them up the same way
+	Levels Levels
+}
+
+// Levels is how a Game's levels are made, written down so that saves and
+// replays can make every level nobody has been to yet the same way: by the
+// Generators named in Generators, as ParseGenerators takes them, with vaults,
+// except for the first level if it's played on a map file.
+type Levels struct {
+	Generators string
+	// MapPath is the name of the map file, which says whether it's JSON or
+	// ASCII, and Map is what was in it
+	MapPath string
+	Map     []byte
+}
+
+// Configure sets up config's Generator and Populate to make levels as l says,
+// with the vaults, monsters and items in config's Content.
+func (l Levels) Configure(config *GameConfig) error {
+	generator, err := ParseGenerators(l.Generators)
+	if err != nil {
+		return err
+	}
+	config.Generator = WithVaults(generator, config.Content)
+	if l.MapPath != "" {
+		levelMap, err := ParseLevelMap(l.MapPath, bytes.NewReader(l.Map), config.Content)
+		if err != nil {
+			return err
+		}
+		// The map is the first level, just as it's drawn
+		config.Generator = LevelGenerators{levelMap, config.Generator}
+		if populate := config.Populate; populate != nil {
+			config.Populate = func(game *Game, dungeon *Dungeon) {
+				if dungeon.Depth() > 0 {
+					populate(game, dungeon)
+				}
+			}
+		}
+	}
+	config.Levels = l
+	return nil
+}
+
+// Default size of each level
+const (
+	DefaultLevelWidth  = 100
+	DefaultLevelHeight = 100
+)
+
+// DefaultGameConfig returns the config for a normal game of GoRL, played in
+// the terminal, with monsters and items made from content.
+func DefaultGameConfig(seed int64, content *Content) GameConfig {
+	return GameConfig{
+		Seed:        seed,
+		Width:       DefaultLevelWidth,
+		Height:      DefaultLevelHeight,
+		Generator:   WithVaults(RoomsGenerator{}, content),
+		Populate:    PopulateLevel,
+		StartingKit: DefaultStartingKit,
+		NewUI:       TermboxUIFactory,
+		Content:     content,
+	}
+}
+
+// validate returns an error if config can't make a Game.
+func (config GameConfig) validate() error {
+	if config.Width <= 0 || config.Height <= 0 {
+		return fmt.Errorf("Bad level size: %dx%d", config.Width, config.Height)
+	}
+	if config.Generator == nil {
+		return fmt.Errorf("No level generator")
+	}
+	if width, height := MinLevelSize(config.Generator); config.Width < width || config.Height < height {
+		return fmt.Errorf("Levels are %dx%d, but the level generator needs them at least %dx%d", config.Width, config.Height, width, height)
+	}
+	if config.NewUI == nil {
+		return fmt.Errorf("No UI")
+	}
+	return nil
+}
+
+// DefaultStartingKit gives the Player a bright torch, a sword to wield,
+// leather armor to wear and a potion of healing.
+func DefaultStartingKit(game *Game, player Player) {
+	for _, id := range []string{"bright torch", "sword", "leather armor", "potion of healing"} {
+		item, err := game.config.Content.NewItem(id)
+		if err != nil {
+			game.log.Panic(err)
+		}
+		player.AddToInventory(item)
+		if weapon, ok := item.(Wieldable); ok {
+			player.Wield(weapon, 0)
+		}
+		if armor, ok := item.(Wearable); ok {
+			player.Equip(armor)
+		}
+	}
+}
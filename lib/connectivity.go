@@ -0,0 +1,201 @@
+package gorl
+
+import (
+	"fmt"
+	"math/rand"
+	"sort"
+)
+
+// MinRegionSize is the smallest a pocket of floor can be and still be joined
+// up to the rest of a level. Anything smaller is filled in with rock.
+const MinRegionSize = 5
+
+// regions returns every separate region of floor in the Dungeon, biggest
+// first, where each region is every Tile that can be walked to from any other
+// in it.
+func (d *Dungeon) regions() [][]Vector {
+	var regions [][]Vector
+	seen := make(map[Vector]bool)
+	for y := 0; y < d.height; y++ {
+		for x := 0; x < d.width; x++ {
+			start := Vector{x, y}
+			if seen[start] || !d.Tile(start).Crossable() {
+				continue
+			}
+			region := []Vector{start}
+			seen[start] = true
+			for i := 0; i < len(region); i++ {
+				for _, direction := range pathDirections {
+					next := region[i].Add(direction)
+					if !seen[next] && d.Tile(next).Crossable() {
+						seen[next] = true
+						region = append(region, next)
+					}
+				}
+			}
+			regions = append(regions, region)
+		}
+	}
+	// Stable, so that regions the same size stay in the order they were found
+	sort.SliceStable(regions, func(i, j int) bool {
+		return len(regions[i]) > len(regions[j])
+	})
+	return regions
+}
+
+// reachable returns every floor Tile that can be walked to from start
+// without going through a locked Door, in the order they're found.
+func (d *Dungeon) reachable(start Vector) []Vector {
+	found := []Vector{start}
+	seen := map[Vector]bool{start: true}
+	for i := 0; i < len(found); i++ {
+		for _, direction := range pathDirections {
+			next := found[i].Add(direction)
+			if !seen[next] && d.Tile(next).Crossable() && !d.lockedAt(next) {
+				seen[next] = true
+				found = append(found, next)
+			}
+		}
+	}
+	return found
+}
+
+// lockedAt returns true if there's a locked Door at loc
+func (d *Dungeon) lockedAt(loc Vector) bool {
+	fg, exists := d.features[loc]
+	if !exists {
+		return false
+	}
+	door, ok := fg.feature.(Door)
+	return ok && door.State() == DoorLocked
+}
+
+// regionCenter returns the location in region closest to its middle
+func regionCenter(region []Vector) Vector {
+	var sum Vector
+	for _, loc := range region {
+		sum = sum.Add(loc)
+	}
+	middle := Vector{sum.x / len(region), sum.y / len(region)}
+	center := region[0]
+	for _, loc := range region[1:] {
+		if distanceSquared(loc, middle) < distanceSquared(center, middle) {
+			center = loc
+		}
+	}
+	return center
+}
+
+func distanceSquared(a, b Vector) int {
+	offset := a.Sub(b)
+	return offset.x*offset.x + offset.y*offset.y
+}
+
+// connect makes sure every floor Tile in a freshly generated Dungeon can be
+// reached from every other. Pockets smaller than MinRegionSize are filled in,
+// and the rest are joined up by corridors along a minimum spanning tree of
+// their centers, which never dig through a vault. Then the origin is moved to
+// the nearest floor, if it isn't on any. Returns an error if the Dungeon still
+// isn't connected.
+func (d *Dungeon) connect(dice *rand.Rand) error {
+	regions := d.regions()
+	if len(regions) == 0 {
+		return fmt.Errorf("Level at depth %d has no floor", d.depth)
+	}
+	for len(regions) > 1 && len(regions[len(regions)-1]) < MinRegionSize {
+		for _, loc := range regions[len(regions)-1] {
+			d.tiles[loc.y][loc.x] = newWall()
+		}
+		regions = regions[:len(regions)-1]
+	}
+
+	centers := make([]Vector, len(regions))
+	for i, region := range regions {
+		centers[i] = regionCenter(region)
+	}
+	// Prim's algorithm: join whichever region is closest to the ones already
+	// joined, until they all are
+	joined := make([]bool, len(centers))
+	joined[0] = true
+	for n := 1; n < len(centers); n++ {
+		from, to := -1, -1
+		for i := range centers {
+			if !joined[i] {
+				continue
+			}
+			for j := range centers {
+				if joined[j] {
+					continue
+				}
+				if from < 0 || distanceSquared(centers[i], centers[j]) < distanceSquared(centers[from], centers[to]) {
+					from, to = i, j
+				}
+			}
+		}
+		d.joinUp(centers[from], centers[to], dice)
+		joined[to] = true
+	}
+
+	if regions = d.regions(); len(regions) != 1 {
+		return fmt.Errorf("Level at depth %d is still in %d pieces after joining it up", d.depth, len(regions))
+	}
+	if !d.Tile(d.origin).Crossable() || !d.featuresAt(d.origin).Crossable() {
+		origin, ok := d.FreeLocNear(d.origin)
+		if !ok {
+			return fmt.Errorf("Level at depth %d has nowhere to start", d.depth)
+		}
+		d.origin = origin
+	}
+	return nil
+}
+
+// joinUp digs an L shaped corridor from from to to like digCorridor, as long
+// as it doesn't dig through a vault. If it would either way round, it digs
+// the shortest way around the vaults instead.
+func (d *Dungeon) joinUp(from, to Vector, dice *rand.Rand) {
+	corners := []Vector{{to.x, from.y}, {from.x, to.y}}
+	if dice.Intn(2) == 0 {
+		corners[0], corners[1] = corners[1], corners[0]
+	}
+	for _, corner := range corners {
+		corridor := corridorVia(from, corner, to)
+		clear := true
+		for _, loc := range corridor {
+			clear = clear && d.canDig(loc)
+		}
+		if clear {
+			for _, loc := range corridor {
+				d.tiles[loc.y][loc.x] = newFloor()
+			}
+			return
+		}
+	}
+
+	cameFrom := map[Vector]Vector{from: from}
+	queue := []Vector{from}
+	for len(queue) > 0 {
+		loc := queue[0]
+		queue = queue[1:]
+		if loc == to {
+			for ; loc != from; loc = cameFrom[loc] {
+				d.tiles[loc.y][loc.x] = newFloor()
+			}
+			return
+		}
+		for _, direction := range walkDirections {
+			next := loc.Add(direction)
+			if _, seen := cameFrom[next]; seen || next.x < 0 || next.y < 0 || next.x >= d.width || next.y >= d.height || !d.canDig(next) {
+				continue
+			}
+			cameFrom[next] = loc
+			queue = append(queue, next)
+		}
+	}
+	d.log.Printf("No way from %s to %s that misses the vaults", from, to)
+}
+
+// canDig returns true if loc is already floor, or isn't in a vault, so that
+// digging a corridor through it won't break into one.
+func (d *Dungeon) canDig(loc Vector) bool {
+	return d.Tile(loc).Crossable() || d.PlaceAt(loc) != PlaceVault
+}
@@ -0,0 +1,61 @@
+package gorl
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func TestConnect(t *testing.T) {
+	d := newTestDungeon(
+		"############",
+		"#...#####..#",
+		"#...#####..#",
+		"#...#####..#",
+		"#########..#",
+		"######.##..#",
+		"############",
+	)
+	d.origin = Vector{6, 3}
+	if regions := d.regions(); len(regions) != 3 || len(regions[0]) != 10 || len(regions[2]) != 1 {
+		t.Fatalf("regions() = %v; want regions of 10, 9 and 1 tiles", regions)
+	}
+	if err := d.connect(rand.New(rand.NewSource(1))); err != nil {
+		t.Fatal(err)
+	}
+	if regions := d.regions(); len(regions) != 1 {
+		t.Errorf("%d regions after connect(); want 1", len(regions))
+	}
+	if d.Tile(Vector{6, 5}).Crossable() {
+		t.Error("Single tile pocket wasn't filled in")
+	}
+	if !d.Tile(d.origin).Crossable() || d.origin.Sub(Vector{6, 3}).Distance() > 3 {
+		t.Errorf("Origin moved to %s; want the nearest floor to (6, 3)", d.origin)
+	}
+
+	if err := newTestDungeon("###", "###").connect(rand.New(rand.NewSource(1))); err == nil {
+		t.Error("connect() joined up a level with no floor")
+	}
+
+	// The way between these two is round the vault, not through it
+	d = newTestDungeon(
+		"#########",
+		"#..###..#",
+		"#..###..#",
+		"#########",
+		"#########",
+		"#########",
+	)
+	d.origin = Vector{1, 1}
+	vault := Rectangle{Vector{3, 0}, Vector{3, 4}}
+	d.rooms = append(d.rooms, Room{vault, "pillar"})
+	if err := d.connect(rand.New(rand.NewSource(1))); err != nil {
+		t.Fatal(err)
+	}
+	for y := 0; y < 4; y++ {
+		for x := 3; x < 6; x++ {
+			if d.Tile(Vector{x, y}).Crossable() {
+				t.Errorf("connect() dug through the vault at (%d, %d)", x, y)
+			}
+		}
+	}
+}
@@ -0,0 +1,174 @@
+package gorl
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ConsumableKind is what sort of thing a Usable Item is, and so how it's used
+type ConsumableKind uint
+
+const (
+	ConsumablePotion ConsumableKind = iota
+	ConsumableScroll
+	ConsumableFood
+)
+
+type consumableKindInfo struct {
+	name, verb, pastTense string
+}
+
+var consumableKinds = []consumableKindInfo{
+	ConsumablePotion: {"potion", "quaff", "quaffed"},
+	ConsumableScroll: {"scroll", "read", "read"},
+	ConsumableFood:   {"food", "eat", "ate"},
+}
+
+func (k ConsumableKind) String() string {
+	if int(k) < len(consumableKinds) {
+		return consumableKinds[k].name
+	}
+	return fmt.Sprintf("ConsumableKind(%d)", k)
+}
+
+// Verb is how something of this kind is used, e.g. "quaff"
+func (k ConsumableKind) Verb() string {
+	if int(k) < len(consumableKinds) {
+		return consumableKinds[k].verb
+	}
+	return "use"
+}
+
+// PastTense is Verb in the past tense, e.g. "quaffed"
+func (k ConsumableKind) PastTense() string {
+	if int(k) < len(consumableKinds) {
+		return consumableKinds[k].pastTense
+	}
+	return "used"
+}
+
+// ParseConsumableKind returns the ConsumableKind called name
+func ParseConsumableKind(name string) (ConsumableKind, error) {
+	names := make([]string, len(consumableKinds))
+	for k, info := range consumableKinds {
+		if info.name == name {
+			return ConsumableKind(k), nil
+		}
+		names[k] = info.name
+	}
+	return 0, fmt.Errorf("Unknown consumable %q, want one of %s", name, strings.Join(names, ", "))
+}
+
+// UseKind is something that happens when an Item is used
+type UseKind uint
+
+const (
+	// UseHeal heals the user by Magnitude
+	UseHeal UseKind = iota
+	// UseLight makes the user glow with a light radius of Magnitude
+	UseLight
+	// UseTeleport moves the user somewhere random on the level
+	UseTeleport
+	// UseMapping shows the user the whole level
+	UseMapping
+)
+
+var useKindNames = []string{
+	UseHeal:     "heal",
+	UseLight:    "light",
+	UseTeleport: "teleport",
+	UseMapping:  "mapping",
+}
+
+func (k UseKind) String() string {
+	if int(k) < len(useKindNames) {
+		return useKindNames[k]
+	}
+	return fmt.Sprintf("UseKind(%d)", k)
+}
+
+// ParseUseKind returns the UseKind called name
+func ParseUseKind(name string) (UseKind, error) {
+	for k, useName := range useKindNames {
+		if useName == name {
+			return UseKind(k), nil
+		}
+	}
+	return 0, fmt.Errorf("Unknown use %q, want one of %s", name, strings.Join(useKindNames, ", "))
+}
+
+// A Use is one thing that happens when an Item is used
+type Use struct {
+	Kind      UseKind
+	Magnitude int
+}
+
+// A Usable Item is used up when it's used, doing each of its Uses and putting
+// its Effects on the user.
+type Usable interface {
+	Item
+	Kind() ConsumableKind
+	Uses() []Use
+	Effects() []Effect
+}
+
+func init() {
+	RegisterFeatureType("consumable", &consumable{}, consumableRecord{},
+		func(f Feature, s *Saver) (interface{}, error) {
+			c := f.(*consumable)
+			return consumableRecord{c.item.record(), c.kind, c.uses, c.effects}, nil
+		},
+		func(record interface{}, l *Loader) (Feature, error) {
+			r := record.(consumableRecord)
+			c := &consumable{}
+			c.item.restore(r.Item)
+			c.kind = r.Kind
+			c.uses = r.Uses
+			c.effects = r.Effects
+			return c, nil
+		},
+	)
+}
+
+type consumable struct {
+	item
+	kind    ConsumableKind
+	uses    []Use
+	effects []Effect
+}
+
+// NewConsumable creates and returns a Usable of kind that does uses and puts
+// effects on whoever uses it.
+func NewConsumable(name string, char rune, weight int, kind ConsumableKind, uses []Use, effects []Effect) Usable {
+	c := consumable{
+		item{
+			*NewFeature(name, char).(*feature),
+			weight,
+		},
+		kind,
+		uses,
+		effects,
+	}
+	c.flags |= FlagCrossable
+	return &c
+}
+
+func (c *consumable) Kind() ConsumableKind {
+	return c.kind
+}
+
+func (c *consumable) Uses() []Use {
+	return c.uses
+}
+
+func (c *consumable) Effects() []Effect {
+	return c.effects
+}
+
+// consumableRecord is the saved form of a consumable
+type consumableRecord struct {
+	Item    itemRecord
+	Kind    ConsumableKind
+	Uses    []Use
+	Effects []Effect
+}
@@ -0,0 +1,640 @@
+package gorl
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/RWJMurphy/gorl/lib/roll"
+	"github.com/nsf/termbox-go"
+)
+
+// Files in a content directory
+const (
+	MonstersFile = "monsters.json"
+	ItemsFile    = "items.json"
+	// SpawnsFile is optional; without it, levels are left empty
+	SpawnsFile = "spawns.json"
+)
+
+// DefaultContentDir is the name of the directory the game looks for its
+// content files in, unless told otherwise
+const DefaultContentDir = "data"
+
+// FindContentDir returns where the game's own content files are: in
+// DefaultContentDir next to the executable, or, failing that, in the working
+// directory, as when the game is run with go run.
+func FindContentDir() string {
+	exe, err := os.Executable()
+	if err == nil {
+		exe, err = filepath.EvalSymlinks(exe)
+	}
+	if err != nil {
+		return DefaultContentDir
+	}
+	return contentDirNear(exe)
+}
+
+// contentDirNear returns DefaultContentDir in the directory holding exe if
+// there is one, or in the working directory otherwise.
+func contentDirNear(exe string) string {
+	dir := filepath.Join(filepath.Dir(exe), DefaultContentDir)
+	if info, err := os.Stat(dir); err == nil && info.IsDir() {
+		return dir
+	}
+	return DefaultContentDir
+}
+
+// An ItemTemplate describes a kind of Item. Items with damage are weapons, and
+// items with a slot are armor worn there. Damage is a dice expression, like
+// "2d6+1", of slashing damage unless the damage type says otherwise. Extra
+// damage maps more damage types to dice, like {"fire": "1d4"}. Resistances
+// map damage types to percentages, as in Resistances. A weapon's on hit
+// effects are applied to whatever it damages, and a weapon with a range can
+// be fired that far. Consumable items are potions, scrolls or food, used up to
+// do each of their uses and put their effects on the user. Light color is
+// written like "#ff9933", and is white if left out.
+type ItemTemplate struct {
+	ID          string            `json:"id"`
+	Name        string            `json:"name"`
+	Glyph       string            `json:"glyph"`
+	Color       string            `json:"color"`
+	Weight      int               `json:"weight"`
+	LightRadius int               `json:"light_radius"`
+	LightColor  string            `json:"light_color"`
+	Damage      string            `json:"damage"`
+	DamageType  string            `json:"damage_type"`
+	ExtraDamage map[string]string `json:"extra_damage"`
+	Accuracy    int               `json:"accuracy"`
+	Range       int               `json:"range"`
+	Slot        string            `json:"slot"`
+	Defense     uint              `json:"defense"`
+	Resistances map[string]int    `json:"resistances"`
+	OnHit       []EffectTemplate  `json:"on_hit"`
+	Consumable  string            `json:"consumable"`
+	Uses        []UseTemplate     `json:"uses"`
+	Effects     []EffectTemplate  `json:"effects"`
+
+	char        rune
+	color       termbox.Attribute
+	lightColor  Light
+	damage      []DamageRoll
+	resistances Resistances
+	onHit       []Effect
+	consumable  ConsumableKind
+	uses        []Use
+	effects     []Effect
+}
+
+// A MonsterTemplate describes a kind of Mob. Health, damage and speed default
+// to a normal Mob's if left out. Damage is rolled when the monster hits
+// without a weapon, and is blunt unless the damage type says otherwise;
+// damage, extra damage, resistances and on hit effects are as in
+// ItemTemplate. The monster starts carrying one of each Item in its inventory,
+// wields the first weapon among them and wears the first armor for each slot.
+// It leaves its corpse Item behind when it dies, or an ordinary corpse if it
+// has none. A monster that blocks sight is too big to see past.
+type MonsterTemplate struct {
+	ID          string            `json:"id"`
+	Name        string            `json:"name"`
+	Glyph       string            `json:"glyph"`
+	Color       string            `json:"color"`
+	Health      uint              `json:"health"`
+	Damage      string            `json:"damage"`
+	DamageType  string            `json:"damage_type"`
+	ExtraDamage map[string]string `json:"extra_damage"`
+	Accuracy    int               `json:"accuracy"`
+	Evasion     int               `json:"evasion"`
+	Resistances map[string]int    `json:"resistances"`
+	Vision      int               `json:"vision"`
+	BlocksSight bool              `json:"blocks_sight"`
+	Speed       uint              `json:"speed"`
+	AI          string            `json:"ai"`
+	Inventory   []string          `json:"inventory"`
+	Corpse      string            `json:"corpse"`
+	OnHit       []EffectTemplate  `json:"on_hit"`
+
+	char        rune
+	color       termbox.Attribute
+	ai          AI
+	damage      []DamageRoll
+	resistances Resistances
+	onHit       []Effect
+}
+
+// An EffectTemplate describes an Effect, like
+// {"effect": "poison", "turns": 5, "magnitude": 1}.
+type EffectTemplate struct {
+	Effect    string `json:"effect"`
+	Turns     uint   `json:"turns"`
+	Magnitude int    `json:"magnitude"`
+}
+
+// A UseTemplate describes a Use, like {"use": "heal", "magnitude": 10}.
+type UseTemplate struct {
+	Use       string `json:"use"`
+	Magnitude int    `json:"magnitude"`
+}
+
+var errNoContent = errors.New("No content loaded")
+
+// Content is every kind of monster and item the game knows how to make, and
+// the vaults it can put them in.
+type Content struct {
+	Monsters map[string]*MonsterTemplate
+	Items    map[string]*ItemTemplate
+	Vaults   []*Vault
+	Spawns   *SpawnTables
+}
+
+// LoadContentDir loads and validates the content files in dir, the spawn
+// tables if there are any, and any vault files in its vaults directory.
+func LoadContentDir(dir string) (*Content, error) {
+	monsters, err := ioutil.ReadFile(filepath.Join(dir, MonstersFile))
+	if err != nil {
+		return nil, err
+	}
+	items, err := ioutil.ReadFile(filepath.Join(dir, ItemsFile))
+	if err != nil {
+		return nil, err
+	}
+	content, err := LoadContent(bytes.NewReader(monsters), bytes.NewReader(items))
+	if err != nil {
+		return nil, err
+	}
+	spawns, err := ioutil.ReadFile(filepath.Join(dir, SpawnsFile))
+	if err == nil {
+		err = content.LoadSpawns(bytes.NewReader(spawns))
+	}
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	vaultPaths, err := filepath.Glob(filepath.Join(dir, VaultsDir, "*.txt"))
+	if err != nil {
+		return nil, err
+	}
+	for _, path := range vaultPaths {
+		if err := content.loadVaultFile(path); err != nil {
+			return nil, err
+		}
+	}
+	return content, nil
+}
+
+// loadVaultFile adds the vault in the file at path, named after the file.
+func (c *Content) loadVaultFile(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	name := strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	vault, err := ParseVault(name, f, c)
+	if err != nil {
+		return fmt.Errorf("%s: %s", filepath.Join(VaultsDir, filepath.Base(path)), err)
+	}
+	c.Vaults = append(c.Vaults, vault)
+	return nil
+}
+
+// LoadContent loads and validates monster and item templates, each a JSON
+// list. Errors name the file and template at fault.
+func LoadContent(monsters, items io.Reader) (*Content, error) {
+	content := &Content{
+		Monsters: make(map[string]*MonsterTemplate),
+		Items:    make(map[string]*ItemTemplate),
+	}
+
+	var itemTemplates []*ItemTemplate
+	if err := decodeContent(items, &itemTemplates); err != nil {
+		return nil, fmt.Errorf("%s: %s", ItemsFile, err)
+	}
+	for i, t := range itemTemplates {
+		if err := t.validate(); err != nil {
+			return nil, fmt.Errorf("%s: item %d (%q): %s", ItemsFile, i, t.ID, err)
+		}
+		if _, exists := content.Items[t.ID]; exists {
+			return nil, fmt.Errorf("%s: item %d: %q is defined twice", ItemsFile, i, t.ID)
+		}
+		content.Items[t.ID] = t
+	}
+
+	var monsterTemplates []*MonsterTemplate
+	if err := decodeContent(monsters, &monsterTemplates); err != nil {
+		return nil, fmt.Errorf("%s: %s", MonstersFile, err)
+	}
+	for i, t := range monsterTemplates {
+		if err := t.validate(content); err != nil {
+			return nil, fmt.Errorf("%s: monster %d (%q): %s", MonstersFile, i, t.ID, err)
+		}
+		if _, exists := content.Monsters[t.ID]; exists {
+			return nil, fmt.Errorf("%s: monster %d: %q is defined twice", MonstersFile, i, t.ID)
+		}
+		content.Monsters[t.ID] = t
+	}
+	return content, nil
+}
+
+// decodeContent decodes the JSON in r into v, refusing unknown fields, and
+// pointing out where any syntax error is.
+func decodeContent(r io.Reader, v interface{}) error {
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	err = decoder.Decode(v)
+	if err == io.ErrUnexpectedEOF {
+		line := bytes.Count(data, []byte("\n")) + 1
+		return fmt.Errorf("line %d: file ends in the middle of the JSON", line)
+	}
+	switch e := err.(type) {
+	case *json.SyntaxError:
+		line := bytes.Count(data[:e.Offset], []byte("\n")) + 1
+		return fmt.Errorf("line %d: %s", line, e)
+	case *json.UnmarshalTypeError:
+		line := bytes.Count(data[:e.Offset], []byte("\n")) + 1
+		return fmt.Errorf("line %d: %s", line, e)
+	}
+	return err
+}
+
+// parseGlyph returns the single character in glyph
+func parseGlyph(glyph string) (rune, error) {
+	if utf8.RuneCountInString(glyph) != 1 {
+		return 0, fmt.Errorf("glyph %q must be a single character", glyph)
+	}
+	c, _ := utf8.DecodeRuneInString(glyph)
+	return c, nil
+}
+
+var contentColors = map[string]termbox.Attribute{
+	"":        termbox.ColorDefault,
+	"default": termbox.ColorDefault,
+	"black":   termbox.ColorBlack,
+	"red":     termbox.ColorRed,
+	"green":   termbox.ColorGreen,
+	"yellow":  termbox.ColorYellow,
+	"blue":    termbox.ColorBlue,
+	"magenta": termbox.ColorMagenta,
+	"cyan":    termbox.ColorCyan,
+	"white":   termbox.ColorWhite,
+}
+
+var contentAttributes = map[string]termbox.Attribute{
+	"bold":      termbox.AttrBold,
+	"underline": termbox.AttrUnderline,
+	"reverse":   termbox.AttrReverse,
+}
+
+// parseColor parses a color name, optionally followed by attributes, like
+// "green+bold".
+func parseColor(color string) (termbox.Attribute, error) {
+	parts := strings.Split(color, "+")
+	attr, ok := contentColors[parts[0]]
+	if !ok {
+		return 0, fmt.Errorf("unknown color %q", parts[0])
+	}
+	for _, part := range parts[1:] {
+		a, ok := contentAttributes[part]
+		if !ok {
+			return 0, fmt.Errorf("unknown attribute %q in color %q", part, color)
+		}
+		attr |= a
+	}
+	return attr, nil
+}
+
+// formatColor writes color the way parseColor reads it
+func formatColor(color termbox.Attribute) string {
+	parts := []string{"default"}
+	for name, attr := range contentColors {
+		if name != "" && name != "default" && attr == color&^(termbox.AttrBold|termbox.AttrUnderline|termbox.AttrReverse) {
+			parts[0] = name
+		}
+	}
+	for _, name := range []string{"bold", "underline", "reverse"} {
+		if color&contentAttributes[name] != 0 {
+			parts = append(parts, name)
+		}
+	}
+	return strings.Join(parts, "+")
+}
+
+// formatLight writes light the way parseLight reads it
+func formatLight(light Light) string {
+	channel := func(c float64) int {
+		return int(math.Round(c * 255))
+	}
+	return fmt.Sprintf("#%02x%02x%02x", channel(light.R), channel(light.G), channel(light.B))
+}
+
+// parseLight parses a light color written like "#ff9933", or white if it's
+// left out
+func parseLight(color string) (Light, error) {
+	if color == "" {
+		return WhiteLight, nil
+	}
+	var r, g, b uint8
+	if n, err := fmt.Sscanf(color, "#%02x%02x%02x", &r, &g, &b); err != nil || n != 3 || len(color) != 7 {
+		return Light{}, fmt.Errorf("light color %q isn't like #rrggbb", color)
+	}
+	return Light{float64(r) / 255, float64(g) / 255, float64(b) / 255}, nil
+}
+
+// parseDamage parses dice of damageType, or defaultType if that's left out,
+// and any extra damage, in order of damage type.
+func parseDamage(dice, damageType string, defaultType DamageType, extra map[string]string) ([]DamageRoll, error) {
+	var err error
+	first := DamageRoll{Type: defaultType}
+	if damageType != "" {
+		if first.Type, err = ParseDamageType(damageType); err != nil {
+			return nil, err
+		}
+	}
+	if first.Dice, err = roll.Parse(dice); err != nil {
+		return nil, err
+	}
+	rolls := []DamageRoll{first}
+	for t := range damageTypeNames {
+		extraDice, ok := extra[DamageType(t).String()]
+		if !ok {
+			continue
+		}
+		d, err := roll.Parse(extraDice)
+		if err != nil {
+			return nil, err
+		}
+		rolls = append(rolls, DamageRoll{DamageType(t), d})
+	}
+	for name := range extra {
+		if _, err := ParseDamageType(name); err != nil {
+			return nil, fmt.Errorf("extra_damage: %s", err)
+		}
+	}
+	return rolls, nil
+}
+
+// parseResistances turns damage type names into DamageTypes
+func parseResistances(resistances map[string]int) (Resistances, error) {
+	parsed := make(Resistances)
+	for name, percent := range resistances {
+		t, err := ParseDamageType(name)
+		if err != nil {
+			return nil, fmt.Errorf("resistances: %s", err)
+		}
+		parsed[t] = percent
+	}
+	return parsed, nil
+}
+
+// parseEffects turns the effect templates in field into Effects
+func parseEffects(field string, templates []EffectTemplate) ([]Effect, error) {
+	effects := make([]Effect, len(templates))
+	for i, e := range templates {
+		kind, err := ParseEffectKind(e.Effect)
+		if err != nil {
+			return nil, fmt.Errorf("%s %d: %s", field, i, err)
+		}
+		if e.Turns == 0 {
+			return nil, fmt.Errorf("%s %d: %s lasts no turns", field, i, kind)
+		}
+		effects[i] = Effect{kind, e.Turns, e.Magnitude}
+	}
+	return effects, nil
+}
+
+// parseUses turns use templates into Uses
+func parseUses(templates []UseTemplate) ([]Use, error) {
+	uses := make([]Use, len(templates))
+	for i, u := range templates {
+		kind, err := ParseUseKind(u.Use)
+		if err != nil {
+			return nil, fmt.Errorf("uses %d: %s", i, err)
+		}
+		if (kind == UseHeal || kind == UseLight) && u.Magnitude <= 0 {
+			return nil, fmt.Errorf("uses %d: %s needs a positive magnitude", i, kind)
+		}
+		uses[i] = Use{kind, u.Magnitude}
+	}
+	return uses, nil
+}
+
+func (t *ItemTemplate) validate() error {
+	var err error
+	if t.ID == "" {
+		return fmt.Errorf("no id")
+	}
+	if t.Name == "" {
+		t.Name = t.ID
+	}
+	if t.char, err = parseGlyph(t.Glyph); err != nil {
+		return err
+	}
+	if t.color, err = parseColor(t.Color); err != nil {
+		return err
+	}
+	if t.Weight < 0 {
+		return fmt.Errorf("weight %d is negative", t.Weight)
+	}
+	if t.LightRadius < 0 {
+		return fmt.Errorf("light_radius %d is negative", t.LightRadius)
+	}
+	if t.lightColor, err = parseLight(t.LightColor); err != nil {
+		return err
+	}
+	if t.Damage != "" {
+		if t.damage, err = parseDamage(t.Damage, t.DamageType, DamageSlashing, t.ExtraDamage); err != nil {
+			return err
+		}
+	} else if t.Accuracy != 0 || t.DamageType != "" || len(t.ExtraDamage) > 0 || len(t.OnHit) > 0 || t.Range != 0 {
+		return fmt.Errorf("has accuracy, damage types, on hit effects or range but no damage")
+	}
+	if t.Range < 0 {
+		return fmt.Errorf("range %d is negative", t.Range)
+	}
+	if t.onHit, err = parseEffects("on_hit", t.OnHit); err != nil {
+		return err
+	}
+	if t.resistances, err = parseResistances(t.Resistances); err != nil {
+		return err
+	}
+	if t.Slot != "" {
+		if t.Damage != "" {
+			return fmt.Errorf("can't be both a weapon and armor")
+		}
+		known := false
+		for _, slot := range MobDefaultWearSlots {
+			known = known || slot == t.Slot
+		}
+		if !known {
+			return fmt.Errorf("unknown slot %q, want one of %s", t.Slot, strings.Join(MobDefaultWearSlots, ", "))
+		}
+	} else if t.Defense > 0 || len(t.Resistances) > 0 {
+		return fmt.Errorf("has defense or resistances but no slot to wear it on")
+	}
+	if t.Consumable != "" {
+		if t.Damage != "" || t.Slot != "" {
+			return fmt.Errorf("can't be consumable and a weapon or armor")
+		}
+		if t.consumable, err = ParseConsumableKind(t.Consumable); err != nil {
+			return err
+		}
+		if t.uses, err = parseUses(t.Uses); err != nil {
+			return err
+		}
+		if t.effects, err = parseEffects("effects", t.Effects); err != nil {
+			return err
+		}
+	} else if len(t.Uses) > 0 || len(t.Effects) > 0 {
+		return fmt.Errorf("has uses or effects but isn't consumable")
+	}
+	return nil
+}
+
+func (t *MonsterTemplate) validate(content *Content) error {
+	var err error
+	if t.ID == "" {
+		return fmt.Errorf("no id")
+	}
+	if t.Name == "" {
+		t.Name = t.ID
+	}
+	if t.char, err = parseGlyph(t.Glyph); err != nil {
+		return err
+	}
+	if t.color, err = parseColor(t.Color); err != nil {
+		return err
+	}
+	if t.Health == 0 {
+		t.Health = MobDefaultHealth
+	}
+	if t.Damage == "" {
+		if t.DamageType != "" || len(t.ExtraDamage) > 0 {
+			return fmt.Errorf("has damage types but no damage")
+		}
+		t.damage = MobDefaultDamage
+	} else if t.damage, err = parseDamage(t.Damage, t.DamageType, DamageBlunt, t.ExtraDamage); err != nil {
+		return err
+	}
+	if t.onHit, err = parseEffects("on_hit", t.OnHit); err != nil {
+		return err
+	}
+	if t.resistances, err = parseResistances(t.Resistances); err != nil {
+		return err
+	}
+	if t.Speed == 0 {
+		t.Speed = MobDefaultSpeed
+	}
+	if t.Vision < 0 {
+		return fmt.Errorf("vision %d is negative", t.Vision)
+	}
+	if t.AI == "" {
+		t.ai = AIHunter
+	} else if t.ai, err = ParseAI(t.AI); err != nil {
+		return err
+	}
+	for _, id := range t.Inventory {
+		if _, ok := content.Items[id]; !ok {
+			return fmt.Errorf("unknown item %q in inventory", id)
+		}
+	}
+	if _, ok := content.Items[t.Corpse]; t.Corpse != "" && !ok {
+		return fmt.Errorf("unknown corpse item %q", t.Corpse)
+	}
+	return nil
+}
+
+// NewItem makes an Item from the template with the ID id.
+func (c *Content) NewItem(id string) (Item, error) {
+	if c == nil {
+		return nil, errNoContent
+	}
+	t, ok := c.Items[id]
+	if !ok {
+		return nil, fmt.Errorf("No item template %q", id)
+	}
+	var i Item
+	if t.Damage != "" {
+		weapon := NewWeapon(t.Name, t.char, t.Weight, t.damage, t.Accuracy)
+		for _, effect := range t.onHit {
+			weapon.AddOnHit(effect)
+		}
+		weapon.SetRange(t.Range)
+		i = weapon
+	} else if t.Slot != "" {
+		armor := NewArmor(t.Name, t.char, t.Weight, t.Slot, t.Defense)
+		for damageType, percent := range t.resistances {
+			armor.SetResistance(damageType, percent)
+		}
+		i = armor
+	} else if t.Consumable != "" {
+		i = NewConsumable(t.Name, t.char, t.Weight, t.consumable, t.uses, t.effects)
+	} else {
+		i = NewItem(t.Name, t.char, t.Weight)
+	}
+	i.SetColor(t.color)
+	i.SetLightRadius(t.LightRadius)
+	i.SetLightColor(t.lightColor)
+	return i, nil
+}
+
+// NewMonster makes a Mob from the template with the ID id, on dungeon.
+func (c *Content) NewMonster(id string, log *log.Logger, dungeon *Dungeon) (Mob, error) {
+	if c == nil {
+		return nil, errNoContent
+	}
+	t, ok := c.Monsters[id]
+	if !ok {
+		return nil, fmt.Errorf("No monster template %q", id)
+	}
+	m := NewMob(t.Name, t.char, log, dungeon)
+	m.SetColor(t.color)
+	m.SetMaxHealth(t.Health)
+	m.SetBaseDamage(t.damage)
+	m.SetAccuracy(t.Accuracy)
+	m.SetEvasion(t.Evasion)
+	for damageType, percent := range t.resistances {
+		m.SetResistance(damageType, percent)
+	}
+	m.SetVisionRadius(t.Vision)
+	m.SetBlocksLight(t.BlocksSight)
+	m.SetSpeed(t.Speed)
+	m.SetAI(t.ai)
+	for _, effect := range t.onHit {
+		m.AddOnHit(effect)
+	}
+
+	wielding := false
+	for _, itemID := range t.Inventory {
+		item, err := c.NewItem(itemID)
+		if err != nil {
+			return nil, err
+		}
+		m.AddToInventory(item)
+		if weapon, ok := item.(Wieldable); ok && !wielding {
+			wielding = m.Wield(weapon, 0)
+		}
+		if armor, ok := item.(Wearable); ok {
+			m.Equip(armor)
+		}
+	}
+	if t.Corpse != "" {
+		corpse, err := c.NewItem(t.Corpse)
+		if err != nil {
+			return nil, err
+		}
+		m.SetCorpse(corpse)
+	}
+	return m, nil
+}
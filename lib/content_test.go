@@ -0,0 +1,106 @@
+package gorl
+
+import (
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// testContent loads the game's own content files.
+func testContent(t *testing.T) *Content {
+	content, err := LoadContentDir(filepath.Join("..", DefaultContentDir))
+	if err != nil {
+		t.Fatalf("Couldn't load content: %s", err)
+	}
+	return content
+}
+
+func TestContentDirNear(t *testing.T) {
+	dir, err := ioutil.TempDir("", "gorl")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	exe := filepath.Join(dir, "gorl")
+	if got := contentDirNear(exe); got != DefaultContentDir {
+		t.Errorf("contentDirNear(%q) = %q with no content there, want %q", exe, got, DefaultContentDir)
+	}
+	want := filepath.Join(dir, DefaultContentDir)
+	if err := os.Mkdir(want, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if got := contentDirNear(exe); got != want {
+		t.Errorf("contentDirNear(%q) = %q, want %q", exe, got, want)
+	}
+}
+
+func TestContentNewMonster(t *testing.T) {
+	content := testContent(t)
+	d := newTestDungeon(".")
+	orc, err := content.NewMonster("orc", log.New(ioutil.Discard, "", 0), d)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if orc.Name() != "orc" || orc.Char() != 'o' || orc.VisionRadius() != 100 {
+		t.Errorf("Orc is %s", orc)
+	}
+	if inventory := orc.Inventory(); len(inventory) != 1 || inventory[0].LightRadius() != 10 {
+		t.Errorf("Orc is carrying %v, want a torch", inventory)
+	}
+
+	sword, err := content.NewItem("sword")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if weapon, ok := sword.(Weapon); !ok || fmt.Sprint(weapon.Damage()) != "[1d8+2 slashing]" {
+		t.Errorf("Sword is %v, want a weapon doing 1d8+2 slashing damage", sword)
+	}
+	flaming, err := content.NewItem("flaming sword")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if damage := fmt.Sprint(flaming.(Weapon).Damage()); damage != "[1d8+2 slashing 1d4 fire]" {
+		t.Errorf("Flaming sword does %s damage", damage)
+	}
+
+	if _, err := content.NewMonster("balrog", nil, d); err == nil {
+		t.Error("Made a monster with no template")
+	}
+}
+
+func TestLoadContentErrors(t *testing.T) {
+	items := `[{"id": "rock", "glyph": "*"}]`
+	tests := []struct {
+		monsters, items, want string
+	}{
+		{`[{"id": "rat", "glyph": "r"`, items, "monsters.json: line 1: file ends in the middle of the JSON"},
+		{`[{"id": "rat", "glyph": "r", "teeth": 3}]`, items, `monsters.json: json: unknown field "teeth"`},
+		{`[{"id": "rat", "glyph": "rr"}]`, items, `monsters.json: monster 0 ("rat"): glyph "rr" must be a single character`},
+		{`[{"id": "rat", "glyph": "r", "color": "puce"}]`, items, `monster 0 ("rat"): unknown color "puce"`},
+		{`[{"id": "rat", "glyph": "r", "ai": "genius"}]`, items, `monster 0 ("rat"): Unknown AI "genius"`},
+		{`[{"id": "rat", "glyph": "r", "inventory": ["cheese"]}]`, items, `monster 0 ("rat"): unknown item "cheese" in inventory`},
+		{`[]`, `[{"id": "rock", "glyph": "*"}, {"id": "rock", "glyph": "*"}]`, `items.json: item 1: "rock" is defined twice`},
+		{`[]`, `[{"id": "rock", "glyph": "*",` + "\n" + `"weight": "heavy"}]`, "items.json: line 2:"},
+		{`[]`, `[{"id": "rock", "glyph": "*", "damage": "2d"}]`, `item 0 ("rock"): roll: bad number of sides`},
+		{`[]`, `[{"id": "rock", "glyph": "*", "damage": "1", "damage_type": "sonic"}]`, `item 0 ("rock"): Unknown damage type "sonic"`},
+		{`[]`, `[{"id": "rock", "glyph": "*", "damage": "1", "extra_damage": {"acid": "1d4"}}]`, `item 0 ("rock"): extra_damage: Unknown damage type "acid"`},
+		{`[{"id": "rat", "glyph": "r", "resistances": {"cheese": 50}}]`, items, `monster 0 ("rat"): resistances: Unknown damage type "cheese"`},
+		{`[{"id": "rat", "glyph": "r", "on_hit": [{"effect": "rabies", "turns": 3}]}]`, items, `monster 0 ("rat"): on_hit 0: Unknown effect "rabies"`},
+		{`[{"id": "rat", "glyph": "r", "on_hit": [{"effect": "poison"}]}]`, items, `monster 0 ("rat"): on_hit 0: poison lasts no turns`},
+		{`[]`, `[{"id": "rock", "glyph": "*", "on_hit": [{"effect": "blind", "turns": 1}]}]`, `item 0 ("rock"): has accuracy, damage types, on hit effects or range but no damage`},
+		{`[]`, `[{"id": "rock", "glyph": "*", "consumable": "gas"}]`, `item 0 ("rock"): Unknown consumable "gas"`},
+		{`[]`, `[{"id": "rock", "glyph": "*", "consumable": "food", "uses": [{"use": "heal"}]}]`, `item 0 ("rock"): uses 0: heal needs a positive magnitude`},
+		{`[]`, `[{"id": "rock", "glyph": "*", "uses": [{"use": "teleport"}]}]`, `item 0 ("rock"): has uses or effects but isn't consumable`},
+		{`[]`, `[{"id": "rock", "glyph": "*", "light_color": "orange"}]`, `item 0 ("rock"): light color "orange" isn't like #rrggbb`},
+	}
+	for _, test := range tests {
+		_, err := LoadContent(strings.NewReader(test.monsters), strings.NewReader(test.items))
+		if err == nil || !strings.Contains(err.Error(), test.want) {
+			t.Errorf("LoadContent(%s, %s) = %v, want an error containing %q", test.monsters, test.items, err, test.want)
+		}
+	}
+}
@@ -0,0 +1,137 @@
+package gorl
+
+import (
+	"fmt"
+	"math/rand"
+	"strings"
+
+	"github.com/RWJMurphy/gorl/lib/roll"
+)
+
+// DamageType is the kind of harm an attack does
+type DamageType uint
+
+const (
+	DamageSlashing DamageType = iota
+	DamagePiercing
+	DamageBlunt
+	DamageFire
+	DamageCold
+	DamagePoison
+)
+
+var damageTypeNames = []string{
+	DamageSlashing: "slashing",
+	DamagePiercing: "piercing",
+	DamageBlunt:    "blunt",
+	DamageFire:     "fire",
+	DamageCold:     "cold",
+	DamagePoison:   "poison",
+}
+
+func (t DamageType) String() string {
+	if int(t) < len(damageTypeNames) {
+		return damageTypeNames[t]
+	}
+	return fmt.Sprintf("DamageType(%d)", t)
+}
+
+// Physical damage is stopped by armor's defense. Anything else goes straight
+// through, and only resistances help.
+func (t DamageType) Physical() bool {
+	return t == DamageSlashing || t == DamagePiercing || t == DamageBlunt
+}
+
+// ParseDamageType returns the DamageType called name
+func ParseDamageType(name string) (DamageType, error) {
+	for t, typeName := range damageTypeNames {
+		if typeName == name {
+			return DamageType(t), nil
+		}
+	}
+	return 0, fmt.Errorf("Unknown damage type %q, want one of %s", name, strings.Join(damageTypeNames, ", "))
+}
+
+// A DamageRoll is the dice rolled for one type of damage when an attack hits.
+type DamageRoll struct {
+	Type DamageType
+	Dice roll.Dice
+}
+
+func (r DamageRoll) String() string {
+	return fmt.Sprintf("%s %s", r.Dice, r.Type)
+}
+
+// Damage is an amount of one type of damage
+type Damage struct {
+	Type   DamageType
+	Amount uint
+}
+
+// A DamagePacket is all the damage a single hit does.
+type DamagePacket []Damage
+
+// rollDamage rolls each of rolls, twice over for a critical hit.
+func rollDamage(rolls []DamageRoll, dice *rand.Rand, critical bool) DamagePacket {
+	packet := make(DamagePacket, 0, len(rolls))
+	for _, r := range rolls {
+		amount := r.Dice.Roll(dice)
+		if critical {
+			amount += r.Dice.Roll(dice)
+		}
+		if amount < 0 {
+			amount = 0
+		}
+		packet = append(packet, Damage{r.Type, uint(amount)})
+	}
+	return packet
+}
+
+// Total is the sum of every type of damage in the packet.
+func (p DamagePacket) Total() uint {
+	var total uint
+	for _, d := range p {
+		total += d.Amount
+	}
+	return total
+}
+
+// Resistances are how well something stands up to each DamageType, as a
+// percentage of damage it shrugs off. 100 is immune; negative resistances are
+// vulnerabilities, so -100 takes double damage. Resistances from a Mob and
+// everything it's wearing add up.
+type Resistances map[DamageType]int
+
+// resist returns what's left of amount after resistance percent.
+func resist(amount uint, percent int) uint {
+	if percent >= 100 {
+		return 0
+	}
+	return amount * uint(100-percent) / 100
+}
+
+// damageRollRecord is the saved form of a DamageRoll
+type damageRollRecord struct {
+	Type DamageType
+	Dice string
+}
+
+func newDamageRollRecords(rolls []DamageRoll) []damageRollRecord {
+	records := make([]damageRollRecord, len(rolls))
+	for i, r := range rolls {
+		records[i] = damageRollRecord{r.Type, r.Dice.String()}
+	}
+	return records
+}
+
+func damageRolls(records []damageRollRecord) ([]DamageRoll, error) {
+	rolls := make([]DamageRoll, len(records))
+	for i, r := range records {
+		dice, err := roll.Parse(r.Dice)
+		if err != nil {
+			return nil, err
+		}
+		rolls[i] = DamageRoll{r.Type, dice}
+	}
+	return rolls, nil
+}
@@ -0,0 +1,152 @@
+package gorl
+
+import (
+	"fmt"
+
+	"github.com/nsf/termbox-go"
+)
+
+// DoorState is whether a Door is open, closed or locked
+type DoorState uint
+
+const (
+	DoorOpen DoorState = iota
+	DoorClosed
+	DoorLocked
+)
+
+func (s DoorState) String() string {
+	switch s {
+	case DoorOpen:
+		return "open"
+	case DoorClosed:
+		return "closed"
+	case DoorLocked:
+		return "locked"
+	default:
+		return fmt.Sprintf("DoorState(%d)", s)
+	}
+}
+
+// A Door can be walked and seen through while it's open, but not while it's
+// closed. A locked Door only opens for a Key that fits its lock.
+type Door interface {
+	Feature
+	State() DoorState
+	SetState(DoorState)
+	// LockID identifies the Keys that fit the Door's lock
+	LockID() string
+}
+
+// A Key unlocks the Doors whose LockID is the same as its own
+type Key interface {
+	Item
+	Fits(Door) bool
+	// LockID identifies the Doors the Key unlocks
+	LockID() string
+}
+
+func init() {
+	RegisterFeatureType("door", &door{}, doorRecord{},
+		func(f Feature, s *Saver) (interface{}, error) {
+			d := f.(*door)
+			return doorRecord{d.feature.record(), d.state, d.lockID}, nil
+		},
+		func(record interface{}, l *Loader) (Feature, error) {
+			r := record.(doorRecord)
+			d := &door{}
+			d.feature.restore(r.Feature)
+			d.state = r.State
+			d.lockID = r.LockID
+			return d, nil
+		},
+	)
+	RegisterFeatureType("key", &key{}, keyRecord{},
+		func(f Feature, s *Saver) (interface{}, error) {
+			k := f.(*key)
+			return keyRecord{k.item.record(), k.lockID}, nil
+		},
+		func(record interface{}, l *Loader) (Feature, error) {
+			r := record.(keyRecord)
+			k := &key{}
+			k.item.restore(r.Item)
+			k.lockID = r.LockID
+			return k, nil
+		},
+	)
+}
+
+type door struct {
+	feature
+	state  DoorState
+	lockID string
+}
+
+// NewDoor returns a new Door in state, which Keys with lockID unlock.
+func NewDoor(state DoorState, lockID string) Door {
+	d := &door{*NewFeature("door", '+').(*feature), state, lockID}
+	d.color = termbox.ColorYellow
+	d.SetState(state)
+	return d
+}
+
+func (d *door) State() DoorState {
+	return d.state
+}
+
+// SetState opens, closes or locks the Door
+func (d *door) SetState(state DoorState) {
+	d.state = state
+	d.flags &= ^(FlagCrossable | FlagBlocksLight)
+	if state == DoorOpen {
+		d.name, d.char = "open door", '\''
+		d.flags |= FlagCrossable
+	} else {
+		// A locked door looks just like any other closed one
+		d.name, d.char = "closed door", '+'
+		d.flags |= FlagBlocksLight
+	}
+}
+
+func (d *door) LockID() string {
+	return d.lockID
+}
+
+type key struct {
+	item
+	lockID string
+}
+
+// NewKey returns a Key that unlocks Doors with lockID
+func NewKey(name string, char rune, weight int, lockID string) Key {
+	k := key{
+		item{
+			*NewFeature(name, char).(*feature),
+			weight,
+		},
+		lockID,
+	}
+	k.flags |= FlagCrossable
+	return &k
+}
+
+func (k *key) Fits(d Door) bool {
+	return d.LockID() != "" && d.LockID() == k.lockID
+}
+
+func (k *key) LockID() string {
+	return k.lockID
+}
+
+// doorRecord is the saved form of a door
+type doorRecord struct {
+	Feature featureRecord
+	State   DoorState
+	LockID  string
+}
+
+// keyRecord is the saved form of a key
+type keyRecord struct {
+	Item   itemRecord
+	LockID string
+}
@@ -3,6 +3,8 @@ package gorl
 import (
 	"fmt"
 	"log"
+	"math/rand"
+	"sort"
 	"strings"
 
 	"github.com/nsf/termbox-go"
@@ -13,6 +15,7 @@ type Tile struct {
 	c     rune
 	color termbox.Attribute
 	flags Flag
+	light Light
 }
 
 func (t Tile) String() string {
@@ -65,13 +68,13 @@ func (f Flag) String() string {
 // NewTile creates and returns a new Tile. The Tile will be rendered as c,
 // in the color color, and has its flags set to flags.
 func NewTile(c rune, color termbox.Attribute, flags Flag) Tile {
-	t := Tile{c, color, flags}
+	t := Tile{c: c, color: color, flags: flags}
 	return t
 }
 
 // InvalidTile represents a section of the Dungeon that is out of bounds, or
 // otherwise not considered "valid".
-var InvalidTile = Tile{' ', termbox.ColorBlack, Flag(0) | FlagBlocksLight}
+var InvalidTile = Tile{c: ' ', color: termbox.ColorBlack, flags: Flag(0) | FlagBlocksLight}
 
 // XXX Should a FeatureGroup be an aspect / member of a Tile? Perhaps a Tile
 // is better thought of as all information about that location in a Dungeon,
@@ -115,6 +118,16 @@ func (f *FeatureGroup) Crossable() bool {
 	return true
 }
 
+// BlocksLight returns true if any Feature in the group blocks light
+func (f *FeatureGroup) BlocksLight() bool {
+	for _, f := range f.Each() {
+		if f.Flags()&FlagBlocksLight != 0 {
+			return true
+		}
+	}
+	return false
+}
+
 func (f *FeatureGroup) AddItem(i Item) {
 	if f.items == nil {
 		f.items = make([]Item, 0)
@@ -157,10 +170,20 @@ func (f *FeatureGroup) LightRadius() int {
 // Dungeon represents a level of the game.
 type Dungeon struct {
 	width, height int
+	depth         int
 	origin        Vector
+	upStairs      Vector
+	downStairs    Vector
 	tiles         [][]Tile
 	features      map[Vector]*FeatureGroup
-	log           *log.Logger
+	// featureOrder caches featureLocs; it is cleared whenever a FeatureGroup
+	// is added to features
+	featureOrder []Vector
+	memories     map[Vector]Memory
+	// rooms are only known while the Dungeon is being generated
+	rooms     []Room
+	scheduler *scheduler
+	log       *log.Logger
 }
 
 // NewDungeon creates and returns a new Dungeon of the specified width and height.
@@ -176,24 +199,43 @@ func NewDungeon(width, height int, log *log.Logger) *Dungeon {
 
 	d := &Dungeon{
 		width, height,
+		0,
+		Vector{width / 2, height / 2},
+		Vector{width / 2, height / 2},
 		Vector{width / 2, height / 2},
 		tiles,
 		make(map[Vector]*FeatureGroup),
+		nil,
+		make(map[Vector]Memory),
+		nil,
+		newScheduler(),
 		log,
 	}
 	return d
 }
 
+// Rooms returns the rooms the Dungeon was generated with
+func (d *Dungeon) Rooms() []Room {
+	rooms := make([]Room, len(d.rooms))
+	copy(rooms, d.rooms)
+	return rooms
+}
+
+// Depth returns how far down the Dungeon is. The first level is depth 0.
+func (d *Dungeon) Depth() int {
+	return d.depth
+}
+
 func (d *Dungeon) MobAt(loc Vector) Mob {
-	return d.FeatureGroup(loc).mob
+	return d.featuresAt(loc).mob
 }
 
 func (d *Dungeon) FeatureAt(loc Vector) Feature {
-	return d.FeatureGroup(loc).feature
+	return d.featuresAt(loc).feature
 }
 
 func (d *Dungeon) ItemsAt(loc Vector) []Item {
-	items := d.FeatureGroup(loc).items
+	items := d.featuresAt(loc).items
 	itemsCopy := make([]Item, len(items))
 	copy(itemsCopy, items)
 	return itemsCopy
@@ -206,10 +248,25 @@ func (d *Dungeon) FeatureGroup(loc Vector) *FeatureGroup {
 			make([]Item, 0),
 			nil,
 		}
+		d.featureOrder = nil
 	}
 	return d.features[loc]
 }
 
+// noFeatures stands in for the FeatureGroup of a location with nothing on it.
+// It must never be modified.
+var noFeatures = &FeatureGroup{}
+
+// featuresAt returns the FeatureGroup at loc for reading. Unlike
+// FeatureGroup(), it doesn't create one where there isn't one already, so
+// the result must not be modified.
+func (d *Dungeon) featuresAt(loc Vector) *FeatureGroup {
+	if fg, exists := d.features[loc]; exists {
+		return fg
+	}
+	return noFeatures
+}
+
 // AddItem adds a Item item to the Dungeon.
 func (d *Dungeon) AddItem(item Item) {
 	loc := item.Loc()
@@ -262,6 +319,8 @@ func (d *Dungeon) AddMob(mob Mob) {
 		)
 	}
 	fg.mob = mob
+	mob.SetDungeon(d)
+	d.scheduler.Add(mob)
 }
 
 func (d *Dungeon) ReapDead() {
@@ -278,6 +337,7 @@ func (d *Dungeon) DeleteMob(mob Mob) {
 	fg := d.FeatureGroup(loc)
 	if fg.mob == mob {
 		fg.mob = nil
+		d.scheduler.Remove(mob)
 	} else {
 		d.log.Panicf("Tried to delete non-existent mob: %s", mob)
 	}
@@ -288,49 +348,141 @@ func (d *Dungeon) DeleteMob(mob Mob) {
 func (d *Dungeon) MoveMob(mob Mob, move Vector) bool {
 	d.log.Printf("%s moving %s", mob, move)
 	dest := mob.Loc().Add(move)
-	if !d.FeatureGroup(dest).Crossable() {
+	if !d.featuresAt(dest).Crossable() {
 		return false
 	}
 	if !d.Tile(dest).Crossable() {
 		return false
 	}
-	d.DeleteMob(mob)
+	d.FeatureGroup(mob.Loc()).mob = nil
 	mob.Move(move)
-	d.AddMob(mob)
+	d.FeatureGroup(mob.Loc()).mob = mob
+	return true
+}
+
+// PlaceMob moves mob straight to loc, wherever it is. Returns false if there's
+// no room for it there.
+func (d *Dungeon) PlaceMob(mob Mob, loc Vector) bool {
+	if !d.Tile(loc).Crossable() || !d.featuresAt(loc).Crossable() {
+		return false
+	}
+	d.FeatureGroup(mob.Loc()).mob = nil
+	mob.SetLoc(loc)
+	d.FeatureGroup(loc).mob = mob
 	return true
 }
 
+// FreeLocTries is how many random locations are tried when looking for
+// somewhere free, before going through every location in the Dungeon.
+const FreeLocTries = 100
+
+// freeAt returns true if a Mob could be put on loc.
+func (d *Dungeon) freeAt(loc Vector) bool {
+	return d.Tile(loc).Crossable() && d.featuresAt(loc).Crossable()
+}
+
+// RandomFreeLoc picks a random location that a Mob could be put on. Returns
+// false if there is nowhere free.
+func (d *Dungeon) RandomFreeLoc(dice *rand.Rand) (Vector, bool) {
+	return d.randomLoc(dice, d.freeAt)
+}
+
+// randomLoc picks a random location that suits. If FreeLocTries random picks
+// all miss, it picks from every location that suits instead, and returns false
+// if there are none.
+func (d *Dungeon) randomLoc(dice *rand.Rand, suits func(Vector) bool) (Vector, bool) {
+	for try := 0; try < FreeLocTries; try++ {
+		loc := Vector{dice.Intn(d.width), dice.Intn(d.height)}
+		if suits(loc) {
+			return loc, true
+		}
+	}
+	var locs []Vector
+	for y := 0; y < d.height; y++ {
+		for x := 0; x < d.width; x++ {
+			if suits(Vector{x, y}) {
+				locs = append(locs, Vector{x, y})
+			}
+		}
+	}
+	if len(locs) == 0 {
+		return Vector{}, false
+	}
+	return locs[dice.Intn(len(locs))], true
+}
+
+// FreeLocNear returns the closest location to loc that a Mob could be put on.
+// Returns false if there is nowhere free.
+func (d *Dungeon) FreeLocNear(loc Vector) (Vector, bool) {
+	for radius := 0; radius < d.width || radius < d.height; radius++ {
+		for y := loc.y - radius; y <= loc.y+radius; y++ {
+			for x := loc.x - radius; x <= loc.x+radius; x++ {
+				candidate := Vector{x, y}
+				if candidate.Sub(loc).Distance() != uint(radius) {
+					continue
+				}
+				if d.freeAt(candidate) {
+					return candidate, true
+				}
+			}
+		}
+	}
+	return loc, false
+}
+
+// featureLocs returns the location of every FeatureGroup in the Dungeon,
+// sorted top to bottom then left to right. The result is shared between
+// calls, so it must not be modified.
+func (d *Dungeon) featureLocs() []Vector {
+	if d.featureOrder != nil {
+		return d.featureOrder
+	}
+	locs := make(vectorsByRow, 0, len(d.features))
+	for loc := range d.features {
+		locs = append(locs, loc)
+	}
+	sort.Sort(locs)
+	d.featureOrder = locs
+	return locs
+}
+
+// Mobs returns every Mob in the Dungeon, in the order they are due to act.
 func (d *Dungeon) Mobs() []Mob {
 	var mobs []Mob
-	for _, fg := range d.features {
-		if fg.mob != nil {
-			mobs = append(mobs, fg.mob)
-		}
+	for _, entry := range d.scheduler.inOrder() {
+		mobs = append(mobs, entry.mob)
 	}
-	return mobs[:len(mobs)]
+	return mobs
 }
 
-// CalculateLighting ranges over each Mob and Feature in the Dungeon, setting
-// FlagLit on any tiles within the Feature's LightRadius that have a clear line
-// sight from the Feature
+// CalculateLighting ranges over each Mob and Feature in the Dungeon that gives
+// off light, setting FlagLit on any tiles within its LightRadius that have a
+// clear line of sight from it. Each Tile is lit by the blend of every light
+// reaching it, fading with distance from the light.
 func (d *Dungeon) CalculateLighting() {
-	var radius int
-	signal := make(chan bool)
-	goroutineCount := 0
-
-	for loc, features := range d.features {
-		radius = features.LightRadius()
-		if radius > 0 {
-			goroutineCount++
-			go func(loc Vector, radius int) {
-				d.FlagByLineOfSight(loc, radius, FlagLit)
-				signal <- true
-			}(loc, radius)
+	for y := range d.tiles {
+		for x := range d.tiles[y] {
+			d.tiles[y][x].light = Light{}
 		}
 	}
-
-	for i := 0; i < goroutineCount; i++ {
-		<-signal
+	for _, loc := range d.featureLocs() {
+		for _, f := range d.features[loc].Each() {
+			radius, color := f.LightRadius(), f.LightColor()
+			if radius <= 0 {
+				continue
+			}
+			// Tiles on the edge of an octant are passed over twice, but should
+			// only be lit once
+			lit := make(map[Vector]bool)
+			d.OnTilesInLineOfSight(loc, radius, func(t *Tile, at Vector) {
+				if lit[at] {
+					return
+				}
+				lit[at] = true
+				t.flags |= FlagLit
+				t.light = t.light.Add(color.Scale(falloff(at.Sub(loc).Distance(), radius)))
+			})
+		}
 	}
 }
 
@@ -343,6 +495,15 @@ func (d *Dungeon) ResetFlag(flag Flag) {
 	}
 }
 
+// SetFlag sets flag on every Tile in the Dungeon
+func (d *Dungeon) SetFlag(flag Flag) {
+	for x := 0; x < d.width; x++ {
+		for y := 0; y < d.height; y++ {
+			d.tiles[y][x].flags |= flag
+		}
+	}
+}
+
 var octantMultiplier = [4][8]int{
 	{1, 0, 0, -1, -1, 0, 0, 1},
 	{0, 1, -1, 0, 0, -1, 1, 0},
@@ -373,24 +534,19 @@ func (d *Dungeon) OnTilesInLineOfSight(origin Vector, radius int, do tileFunc) {
 		return
 	}
 	do(&d.tiles[origin.y][origin.x], origin)
-	signal := make(chan bool)
+	// Octants are cast one after another, rather than concurrently, so that
+	// do always sees Tiles in the same order.
 	for octant := 0; octant < 8; octant++ {
-		go func(origin Vector, radius int, do tileFunc, octant int) {
-			d.castFlag(
-				origin.x, origin.y, 1,
-				1.0, 0.0,
-				radius,
-				octantMultiplier[0][octant],
-				octantMultiplier[1][octant],
-				octantMultiplier[2][octant],
-				octantMultiplier[3][octant],
-				do,
-			)
-			signal <- true
-		}(origin, radius, do, octant)
-	}
-	for octant := 0; octant < 8; octant++ {
-		<-signal
+		d.castFlag(
+			origin.x, origin.y, 1,
+			1.0, 0.0,
+			radius,
+			octantMultiplier[0][octant],
+			octantMultiplier[1][octant],
+			octantMultiplier[2][octant],
+			octantMultiplier[3][octant],
+			do,
+		)
 	}
 }
 
@@ -436,7 +592,7 @@ func (d *Dungeon) castFlag(
 				}
 				if blocked {
 					// we're scanning a row of blocked squares
-					if t.BlocksLight() {
+					if d.Opaque(Vector{mapX, mapY}) {
 						newStartSlope = rightSlope
 						continue
 					} else {
@@ -444,7 +600,7 @@ func (d *Dungeon) castFlag(
 						startSlope = newStartSlope
 					}
 				} else {
-					if t.BlocksLight() && j < radius {
+					if d.Opaque(Vector{mapX, mapY}) && j < radius {
 						// this is a blocking square, start a child scan:
 						blocked = true
 						d.castFlag(cx, cy, j+1, startSlope, leftSlope, radius, xx, xy, yx, yy, do)
@@ -459,6 +615,15 @@ func (d *Dungeon) castFlag(
 	}
 }
 
+// Opaque returns true if light can't get past loc, because the Tile there or
+// anything on it blocks light.
+func (d *Dungeon) Opaque(loc Vector) bool {
+	if d.Tile(loc).BlocksLight() {
+		return true
+	}
+	return d.featuresAt(loc).BlocksLight()
+}
+
 // Tile fetches the Dungeon Tile at (x, y)
 func (d *Dungeon) Tile(loc Vector) *Tile {
 	if loc.x < 0 || loc.x >= d.width || loc.y < 0 || loc.y >= d.height {
@@ -478,6 +643,17 @@ func (t *Tile) Lit() bool {
 	return t.flags&FlagLit != 0
 }
 
+// Light is the light falling on the Tile
+func (t *Tile) Light() Light {
+	return t.light
+}
+
+// Dim returns true if the Tile is too dimly lit to make things out from
+// further away than DimSightRange
+func (t *Tile) Dim() bool {
+	return t.light.Brightness() < DimLight
+}
+
 // Visible returns true if the Tile is within the Player's FOV
 func (t *Tile) Visible() bool {
 	return t.flags&FlagVisible != 0
@@ -492,3 +668,15 @@ func (t *Tile) Seen() bool {
 func (t *Tile) BlocksLight() bool {
 	return t.flags&FlagBlocksLight != 0
 }
+
+// Description names the kind of Tile: floor, wall or doorway
+func (t *Tile) Description() string {
+	switch {
+	case t.Crossable() && t.BlocksLight():
+		return "doorway"
+	case t.Crossable():
+		return "floor"
+	default:
+		return "wall"
+	}
+}
@@ -1,12 +1,130 @@
 package gorl
 
 import (
+	"fmt"
 	"log"
 	"math/rand"
+	"sort"
+	"strings"
 
 	"github.com/nsf/termbox-go"
 )
 
+// Generators are the Generators that can be picked by name
+var Generators = map[string]Generator{
+	"rooms": RoomsGenerator{},
+	"bsp":   DefaultBSPGenerator,
+	"caves": DefaultCaveGenerator,
+	"walk":  DefaultWalkGenerator,
+}
+
+// GeneratorNames returns the names of the Generators, sorted
+func GeneratorNames() []string {
+	names := make([]string, 0, len(Generators))
+	for name := range Generators {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// LevelGenerators makes each level with a different Generator: the first
+// level with the first, the second with the second, and every level past the
+// end with the last.
+type LevelGenerators []Generator
+
+func (g LevelGenerators) Generate(log *log.Logger, dice *rand.Rand, width, height, depth int) *Dungeon {
+	if len(g) == 0 {
+		log.Panic("No level generators")
+	}
+	i := depth
+	if i >= len(g) {
+		i = len(g) - 1
+	}
+	return g[i].Generate(log, dice, width, height, depth)
+}
+
+// MinLevelSize is big enough for every one of the Generators
+func (g LevelGenerators) MinLevelSize() (width, height int) {
+	width, height = 1, 1
+	for _, generator := range g {
+		w, h := MinLevelSize(generator)
+		if w > width {
+			width = w
+		}
+		if h > height {
+			height = h
+		}
+	}
+	return width, height
+}
+
+// ParseGenerators returns the Generators named in names, a comma separated
+// list of one for each level as in LevelGenerators, like "rooms,caves,bsp".
+func ParseGenerators(names string) (Generator, error) {
+	var generators LevelGenerators
+	for _, name := range strings.Split(names, ",") {
+		generator, ok := Generators[strings.TrimSpace(name)]
+		if !ok {
+			return nil, fmt.Errorf("Unknown level generator %q, want one of %s", name, strings.Join(GeneratorNames(), ", "))
+		}
+		generators = append(generators, generator)
+	}
+	if len(generators) == 1 {
+		return generators[0], nil
+	}
+	return generators, nil
+}
+
+// newWall and newFloor make the Tiles levels are dug out of
+func newWall() Tile {
+	return NewTile('#', termbox.ColorYellow, Flag(0)|FlagBlocksLight)
+}
+
+func newFloor() Tile {
+	return NewTile('.', termbox.ColorWhite, Flag(0)|FlagCrossable)
+}
+
+// newSolidDungeon returns a width by height level at depth that's solid
+// rock, ready to be dug out.
+func newSolidDungeon(log *log.Logger, width, height, depth int) *Dungeon {
+	d := NewDungeon(width, height, log)
+	d.depth = depth
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			d.tiles[y][x] = newWall()
+		}
+	}
+	return d
+}
+
+// digCorridor digs an L shaped corridor from from to to, going across or
+// down first at random.
+func digCorridor(d *Dungeon, from, to Vector, dice *rand.Rand) {
+	corner := Vector{to.x, from.y}
+	if dice.Intn(2) == 0 {
+		corner = Vector{from.x, to.y}
+	}
+	for _, loc := range corridorVia(from, corner, to) {
+		d.tiles[loc.y][loc.x] = newFloor()
+	}
+}
+
+// corridorVia returns every location on the L shaped corridor from from to
+// to that turns at corner.
+func corridorVia(from, corner, to Vector) []Vector {
+	locs := append([]Vector{from}, line(from, corner)...)
+	return append(locs, line(corner, to)...)
+}
+
+// A Room is a part of a level its Generator set aside, walls and all: an
+// ordinary room, or a vault.
+type Room struct {
+	Area Rectangle
+	// Vault is the name of the vault the Room is, if it's one
+	Vault string
+}
+
 type dungeonRoom struct {
 	width, height int
 	portals       []Vector
@@ -17,28 +135,33 @@ func newDungeonRoom(width, height int, dice *rand.Rand) *dungeonRoom {
 	tiles := make([][]Tile, height)
 	tilesRaw := make([]Tile, width*height)
 	for i := range tilesRaw {
-		tilesRaw[i] = NewTile('.', termbox.ColorWhite, Flag(0)|FlagCrossable)
+		tilesRaw[i] = newFloor()
 	}
 	for i := range tiles {
 		tiles[i], tilesRaw = tilesRaw[:width], tilesRaw[width:]
 	}
 
-	var edgeTiles []Vector
+	// Corners make poor doorways, as they only lead out diagonally
+	var edgeTiles, sideTiles []Vector
 	for x := 0; x < width; x++ {
 		for y := 0; y < height; y++ {
 			if x == 0 || x == width-1 || y == 0 || y == height-1 {
 				edgeTiles = append(edgeTiles, Vector{x, y})
+				if (x != 0 && x != width-1) || (y != 0 && y != height-1) {
+					sideTiles = append(sideTiles, Vector{x, y})
+				}
 			}
 		}
 	}
 
 	for _, loc := range edgeTiles {
-		tiles[loc.y][loc.x] = NewTile('#', termbox.ColorYellow, Flag(0)|FlagBlocksLight)
+		tiles[loc.y][loc.x] = newWall()
 	}
 
 	var portals []Vector
-	for i := 1; i < 1+dice.Intn(4); i++ {
-		portals = append(portals, edgeTiles[dice.Intn(len(edgeTiles))])
+	portalCount := 1 + dice.Intn(4)
+	for i := 0; i < portalCount; i++ {
+		portals = append(portals, sideTiles[dice.Intn(len(sideTiles))])
 	}
 	room := dungeonRoom{
 		width,
@@ -65,17 +188,32 @@ func (d *Dungeon) paintRoom(room *dungeonRoom, topLeft Vector) []Vector {
 	return newPortals
 }
 
-func GenerateDungeon(log *log.Logger, dice *rand.Rand) *Dungeon {
-	width, height := 100, 100
+// RoomsGenerator makes levels with GenerateDungeon
+type RoomsGenerator struct{}
+
+func (RoomsGenerator) Generate(log *log.Logger, dice *rand.Rand, width, height, depth int) *Dungeon {
+	return GenerateDungeon(log, dice, width, height, depth)
+}
+
+// MinLevelSize leaves room for the biggest rooms, 19 tiles across
+func (RoomsGenerator) MinLevelSize() (width, height int) {
+	return 20, 20
+}
+
+// GenerateDungeon creates a new width by height level at the given depth, with
+// stairs leading up (unless it is the first level) and down. It's open ground
+// strewn with rocks, with overlapping rooms stamped over it.
+func GenerateDungeon(log *log.Logger, dice *rand.Rand, width, height, depth int) *Dungeon {
 	d := NewDungeon(width, height, log)
+	d.depth = depth
 	var tile Tile
 
 	for y := 0; y < height; y++ {
 		for x := 0; x < width; x++ {
 			if dice.Float32() < 0.05 {
-				tile = NewTile('#', termbox.ColorYellow, Flag(0)|FlagBlocksLight)
+				tile = newWall()
 			} else {
-				tile = NewTile('.', termbox.ColorWhite, Flag(0)|FlagCrossable)
+				tile = newFloor()
 			}
 			d.tiles[y][x] = tile
 		}
@@ -92,6 +230,7 @@ func GenerateDungeon(log *log.Logger, dice *rand.Rand) *Dungeon {
 
 		room := newDungeonRoom(roomWidth, roomHeight, dice)
 		roomPortals := d.paintRoom(room, topLeft)
+		d.rooms = append(d.rooms, Room{Rectangle{topLeft, Vector{roomWidth, roomHeight}}, ""})
 		for _, p := range roomPortals {
 			portals = append(portals, p)
 		}
@@ -99,8 +238,101 @@ func GenerateDungeon(log *log.Logger, dice *rand.Rand) *Dungeon {
 
 	log.Printf("Room portals: %s", portals)
 
-	for _, portalLoc := range portals {
-		d.tiles[portalLoc.y][portalLoc.x] = NewTile('+', termbox.ColorWhite, Flag(0)|FlagCrossable|FlagBlocksLight)
+	// Rooms are stamped over each other, so portals can end up opening onto
+	// walls, and whole rooms can end up shut off
+	for _, loc := range portals {
+		d.tiles[loc.y][loc.x] = newFloor()
 	}
+	if err := d.connect(dice); err != nil {
+		log.Panic(err)
+	}
+	d.placeDoors(portals, portals, dice)
+	d.placeStairs(dice)
 	return d
 }
+
+// LockedDoorChance is the chance of any door being locked. Every locked door
+// on a level opens with the same key, which is somewhere on that level.
+const LockedDoorChance = 0.1
+
+// placeDoors puts a closed Door on every room portal that's been dug out,
+// locking some of the ones that are also lockable.
+func (d *Dungeon) placeDoors(portals, lockable []Vector, dice *rand.Rand) {
+	lockID := fmt.Sprintf("depth %d", d.depth)
+	locked := false
+	canLock := make(map[Vector]bool)
+	for _, loc := range lockable {
+		canLock[loc] = true
+	}
+	for _, loc := range portals {
+		if !d.Tile(loc).Crossable() || d.FeatureAt(loc) != nil {
+			// Rooms overlap, so the same portal can come up twice, or be
+			// filled in as a pocket too small to join up
+			continue
+		}
+		state := DoorClosed
+		if canLock[loc] && dice.Float32() < LockedDoorChance {
+			state = DoorLocked
+			locked = true
+		}
+		door := NewDoor(state, lockID)
+		door.SetLoc(loc)
+		d.AddFeature(door)
+	}
+	if locked {
+		key := NewKey(fmt.Sprintf("key to level %d", d.depth+1), '-', 1, lockID)
+		key.SetColor(termbox.ColorYellow | termbox.AttrBold)
+		d.placeKey(key, Rectangle{}, dice)
+	}
+}
+
+// placeKey leaves key somewhere outside area that can be walked to from the
+// origin without going through any locked Door. If there's nowhere, the Doors
+// it fits are unlocked instead, so that none of them can shut the way off.
+func (d *Dungeon) placeKey(key Key, area Rectangle, dice *rand.Rand) {
+	var free []Vector
+	for _, loc := range d.reachable(d.origin) {
+		fg, exists := d.features[loc]
+		if loc == d.origin || area.Contains(loc) || (exists && (fg.feature != nil || fg.mob != nil)) {
+			continue
+		}
+		free = append(free, loc)
+	}
+	if len(free) > 0 {
+		key.SetLoc(free[dice.Intn(len(free))])
+		d.AddItem(key)
+		return
+	}
+	d.log.Printf("Nowhere to leave the %s, so unlocking its doors", key.Name())
+	for _, fg := range d.features {
+		if door, ok := fg.feature.(Door); ok && door.State() == DoorLocked && key.Fits(door) {
+			door.SetState(DoorClosed)
+		}
+	}
+}
+
+// placeStairs puts the up staircase on the Dungeon's origin, where the Player
+// arrives from above, and the down staircase somewhere else.
+func (d *Dungeon) placeStairs(dice *rand.Rand) {
+	if d.depth > 0 {
+		d.tiles[d.origin.y][d.origin.x] = newFloor()
+		if door := d.FeatureAt(d.origin); door != nil {
+			d.DeleteFeature(door)
+		}
+		up := NewStairs(false)
+		up.SetLoc(d.origin)
+		d.AddFeature(up)
+		d.upStairs = d.origin
+	}
+
+	down := NewStairs(true)
+	loc, ok := d.randomLoc(dice, func(loc Vector) bool {
+		return loc != d.origin && d.freeAt(loc)
+	})
+	if !ok {
+		d.log.Panic("Nowhere to put the down stairs")
+	}
+	down.SetLoc(loc)
+	d.AddFeature(down)
+	d.downStairs = loc
+}
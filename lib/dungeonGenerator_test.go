@@ -0,0 +1,197 @@
+package gorl
+
+import (
+	"io/ioutil"
+	"log"
+	"math/rand"
+	"testing"
+)
+
+func TestDungeonGenerationDeterministic(t *testing.T) {
+	logger := log.New(ioutil.Discard, "", 0)
+	config := DefaultGameConfig(1234, testContent(t))
+	config.Width, config.Height = 60, 40
+	a := newGame(logger, config).dungeonAt(1)
+	b := newGame(logger, config).dungeonAt(1)
+
+	for y := 0; y < a.height; y++ {
+		for x := 0; x < a.width; x++ {
+			if a.tiles[y][x] != b.tiles[y][x] {
+				t.Fatalf("tile at (%d, %d) differs: %s, %s", x, y, a.tiles[y][x], b.tiles[y][x])
+			}
+		}
+	}
+
+	aMobs, bMobs := a.Mobs(), b.Mobs()
+	if len(aMobs) != len(bMobs) {
+		t.Fatalf("generated %d and %d Mobs from the same seed", len(aMobs), len(bMobs))
+	}
+	for i := range aMobs {
+		if aMobs[i].Name() != bMobs[i].Name() || aMobs[i].Loc() != bMobs[i].Loc() {
+			t.Errorf("Mob %d differs: %s, %s", i, aMobs[i], bMobs[i])
+		}
+	}
+	if a.downStairs != b.downStairs {
+		t.Errorf("down stairs differ: %s, %s", a.downStairs, b.downStairs)
+	}
+}
+
+func TestGenerators(t *testing.T) {
+	logger := log.New(ioutil.Discard, "", 0)
+	for _, name := range GeneratorNames() {
+		generator := Generators[name]
+		// Every level is joined up, and the stairs down can be got to past
+		// any locked doors, whatever the seed
+		for seed := int64(2); seed < 20; seed++ {
+			d := generator.Generate(logger, rand.New(rand.NewSource(seed)), 60, 40, 1)
+			if regions := d.regions(); len(regions) != 1 {
+				t.Errorf("%s: level from seed %d is in %d pieces", name, seed, len(regions))
+			}
+			if !walkable(d)[d.downStairs] {
+				t.Errorf("%s: level from seed %d has its stairs down at %s shut off", name, seed, d.downStairs)
+			}
+		}
+
+		d := generator.Generate(logger, rand.New(rand.NewSource(1)), 60, 40, 1)
+		again := generator.Generate(logger, rand.New(rand.NewSource(1)), 60, 40, 1)
+		for y := 0; y < d.height; y++ {
+			for x := 0; x < d.width; x++ {
+				if d.tiles[y][x] != again.tiles[y][x] {
+					t.Fatalf("%s: tile at (%d, %d) differs with the same seed", name, x, y)
+				}
+			}
+		}
+
+		if !d.Tile(d.origin).Crossable() || d.upStairs != d.origin {
+			t.Errorf("%s: origin %s is %s, with up stairs at %s", name, d.origin, d.Tile(d.origin), d.upStairs)
+		}
+		if stairs, ok := d.FeatureAt(d.downStairs).(*stairs); !ok || !stairs.Down() {
+			t.Errorf("%s: no down stairs at %s", name, d.downStairs)
+		}
+		floors := 0
+		for y := 0; y < d.height; y++ {
+			for x := 0; x < d.width; x++ {
+				if !d.tiles[y][x].Crossable() {
+					continue
+				}
+				floors++
+				if name != "rooms" && onEdge(x, y, d.width, d.height) {
+					t.Errorf("%s: floor on the edge of the level at (%d, %d)", name, x, y)
+				}
+			}
+		}
+		if floors < d.width*d.height/5 {
+			t.Errorf("%s: only %d floor tiles", name, floors)
+		}
+	}
+}
+
+func TestMinLevelSize(t *testing.T) {
+	logger := log.New(ioutil.Discard, "", 0)
+	content := testContent(t)
+	for _, name := range GeneratorNames() {
+		width, height := MinLevelSize(Generators[name])
+		config := DefaultGameConfig(1, content)
+		config.Generator = WithVaults(Generators[name], content)
+		config.NewUI = NewHeadlessUI(20, 10, NewScriptedActions())
+		for seed := int64(1); seed < 20; seed++ {
+			config.Seed, config.Width, config.Height = seed, width, height
+			game, err := NewGame(logger, config)
+			if err != nil {
+				t.Fatalf("%s: %s", name, err)
+			}
+			game.dungeonAt(1)
+			game.Close()
+		}
+		config.Width = width - 1
+		if _, err := NewGame(logger, config); err == nil {
+			t.Errorf("%s: made a game %dx%d, below its minimum of %dx%d", name, config.Width, config.Height, width, height)
+		}
+	}
+	if width, height := MinLevelSize(LevelGenerators{DefaultBSPGenerator, Generators["rooms"]}); width != 20 || height != 20 {
+		t.Errorf("Smallest level for bsp then rooms is %dx%d, want 20x20", width, height)
+	}
+}
+
+func TestParseGenerators(t *testing.T) {
+	generator, err := ParseGenerators("caves, bsp")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := LevelGenerators{DefaultCaveGenerator, DefaultBSPGenerator}
+	if levels, ok := generator.(LevelGenerators); !ok || len(levels) != 2 || levels[0] != want[0] || levels[1] != want[1] {
+		t.Errorf("ParseGenerators(\"caves, bsp\") = %v; want %v", generator, want)
+	}
+	if generator, err := ParseGenerators("walk"); err != nil || generator != Generators["walk"] {
+		t.Errorf("ParseGenerators(\"walk\") = %v, %v; want the walk generator", generator, err)
+	}
+	if _, err := ParseGenerators("rooms,maze"); err == nil {
+		t.Error("ParseGenerators made an unknown generator")
+	}
+
+	// Levels past the end of the list are made by the last generator
+	logger := log.New(ioutil.Discard, "", 0)
+	made := make(map[int]int)
+	counting := func(i int) Generator {
+		return LevelGenerator(func(log *log.Logger, dice *rand.Rand, width, height, depth int) *Dungeon {
+			made[depth] = i
+			return NewDungeon(width, height, log)
+		})
+	}
+	levels := LevelGenerators{counting(0), counting(1)}
+	for depth := 0; depth < 4; depth++ {
+		levels.Generate(logger, rand.New(rand.NewSource(1)), 5, 5, depth)
+	}
+	if made[0] != 0 || made[1] != 1 || made[2] != 1 || made[3] != 1 {
+		t.Errorf("Levels made by generators %v; want 0, 1, 1, 1", made)
+	}
+}
+
+// walkable returns everywhere on d that can be walked to from the origin,
+// picking up every key on the way and going through the Doors it unlocks.
+func walkable(d *Dungeon) map[Vector]bool {
+	keys := make(map[string]bool)
+	for {
+		held := len(keys)
+		seen := map[Vector]bool{d.origin: true}
+		found := []Vector{d.origin}
+		for i := 0; i < len(found); i++ {
+			for _, item := range d.ItemsAt(found[i]) {
+				if key, ok := item.(Key); ok {
+					keys[key.LockID()] = true
+				}
+			}
+			for _, direction := range pathDirections {
+				next := found[i].Add(direction)
+				if seen[next] || !d.Tile(next).Crossable() {
+					continue
+				}
+				if door, ok := d.FeatureAt(next).(Door); ok && door.State() == DoorLocked && !keys[door.LockID()] {
+					continue
+				}
+				seen[next] = true
+				found = append(found, next)
+			}
+		}
+		if len(keys) == held {
+			return seen
+		}
+	}
+}
+
+func TestLockedDoorsCanBeOpened(t *testing.T) {
+	logger := log.New(ioutil.Discard, "", 0)
+	content := testContent(t)
+	for _, name := range GeneratorNames() {
+		generator := WithVaults(Generators[name], content)
+		for seed := int64(1); seed < 30; seed++ {
+			d := generator.Generate(logger, rand.New(rand.NewSource(seed)), 80, 60, 1)
+			reached := walkable(d)
+			for _, loc := range d.featureLocs() {
+				if d.lockedAt(loc) && !reached[loc] {
+					t.Errorf("%s: level from seed %d has a locked door at %s that can't be got to with its key", name, seed, loc)
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,129 @@
+package gorl
+
+import (
+	"io/ioutil"
+	"log"
+	"math/rand"
+	"testing"
+)
+
+// visibleFrom returns which Tiles of d can be seen from origin within radius
+func visibleFrom(d *Dungeon, origin Vector, radius int) map[Vector]bool {
+	d.ResetFlag(FlagVisible)
+	d.FlagByLineOfSight(origin, radius, FlagVisible)
+	visible := make(map[Vector]bool)
+	for y := 0; y < d.height; y++ {
+		for x := 0; x < d.width; x++ {
+			if d.Tile(Vector{x, y}).Visible() {
+				visible[Vector{x, y}] = true
+			}
+		}
+	}
+	return visible
+}
+
+func TestLineOfSightOctants(t *testing.T) {
+	// The eight ways to turn and flip the map, one for each octant
+	symmetries := []func(Vector) Vector{
+		func(v Vector) Vector { return Vector{v.x, v.y} },
+		func(v Vector) Vector { return Vector{-v.y, v.x} },
+		func(v Vector) Vector { return Vector{-v.x, -v.y} },
+		func(v Vector) Vector { return Vector{v.y, -v.x} },
+		func(v Vector) Vector { return Vector{-v.x, v.y} },
+		func(v Vector) Vector { return Vector{v.x, -v.y} },
+		func(v Vector) Vector { return Vector{v.y, v.x} },
+		func(v Vector) Vector { return Vector{-v.y, -v.x} },
+	}
+	const radius = 5
+	origin := Vector{radius, radius}
+	rows := make([]string, radius*2+1)
+	for i := range rows {
+		rows[i] = "..........."
+	}
+	pillar := Vector{1, -2}
+
+	var want map[Vector]bool
+	for i, turn := range symmetries {
+		d := newTestDungeon(rows...)
+		d.tiles[origin.y+turn(pillar).y][origin.x+turn(pillar).x].flags |= FlagBlocksLight
+		visible := visibleFrom(d, origin, radius)
+
+		if !visible[origin.Add(turn(pillar))] {
+			t.Errorf("symmetry %d: pillar at %s not visible", i, origin.Add(turn(pillar)))
+		}
+		if behind := origin.Add(turn(pillar.Mul(2))); visible[behind] {
+			t.Errorf("symmetry %d: %s behind pillar is visible", i, behind)
+		}
+		if want == nil {
+			want = make(map[Vector]bool)
+			for loc := range visible {
+				want[loc.Sub(origin)] = true
+			}
+			continue
+		}
+		// Every octant should see its own pillar's shadow the same way
+		for y := -radius; y <= radius; y++ {
+			for x := -radius; x <= radius; x++ {
+				offset := Vector{x, y}
+				if got := visible[origin.Add(turn(offset))]; got != want[offset] {
+					t.Errorf("symmetry %d: %s visible = %t; want %t", i, origin.Add(turn(offset)), got, want[offset])
+				}
+			}
+		}
+	}
+}
+
+func TestFeaturesBlockLight(t *testing.T) {
+	d := newTestDungeon(
+		".......",
+	)
+	origin, blocker, behind := Vector{0, 0}, Vector{2, 0}, Vector{4, 0}
+	if !visibleFrom(d, origin, 6)[behind] {
+		t.Fatalf("%s not visible down an empty corridor", behind)
+	}
+
+	door := NewDoor(DoorClosed, "")
+	door.SetLoc(blocker)
+	d.AddFeature(door)
+	if visible := visibleFrom(d, origin, 6); !visible[blocker] || visible[behind] {
+		t.Errorf("closed door: visible = %t, %t; want true, false", visible[blocker], visible[behind])
+	}
+	door.SetState(DoorOpen)
+	if visible := visibleFrom(d, origin, 6); !visible[behind] {
+		t.Errorf("open door: %s not visible", behind)
+	}
+	d.DeleteFeature(door)
+
+	mob := NewMob("giant", 'G', log.New(ioutil.Discard, "", 0), d)
+	d.PlaceMob(mob, blocker)
+	if !visibleFrom(d, origin, 6)[behind] {
+		t.Errorf("%s hidden by a Mob that doesn't block sight", behind)
+	}
+	mob.SetBlocksLight(true)
+	if visible := visibleFrom(d, origin, 6); !visible[blocker] || visible[behind] {
+		t.Errorf("Mob blocking sight: visible = %t, %t; want true, false", visible[blocker], visible[behind])
+	}
+	if !d.Opaque(blocker) || d.Opaque(behind) {
+		t.Errorf("Opaque(%s), Opaque(%s) = %t, %t; want true, false", blocker, behind, d.Opaque(blocker), d.Opaque(behind))
+	}
+}
+
+func TestRandomFreeLoc(t *testing.T) {
+	dice := rand.New(rand.NewSource(1))
+	// Random picks will almost always miss the one free Tile
+	d := newTestDungeon(
+		"##############################",
+		"#############.################",
+		"##############################",
+	)
+	if loc, ok := d.RandomFreeLoc(dice); !ok || loc != (Vector{13, 1}) {
+		t.Errorf("RandomFreeLoc() = %s, %t; want (13, 1), true", loc, ok)
+	}
+
+	mob := NewMob("giant", 'G', log.New(ioutil.Discard, "", 0), d)
+	mob.SetLoc(Vector{13, 1})
+	d.AddMob(mob)
+	if loc, ok := d.RandomFreeLoc(dice); ok {
+		t.Errorf("RandomFreeLoc() = %s with nowhere free", loc)
+	}
+}
@@ -0,0 +1,138 @@
+package gorl
+
+import (
+	"fmt"
+	"strings"
+)
+
+// EffectKind is a kind of status effect
+type EffectKind uint
+
+const (
+	// EffectPoison does Magnitude poison damage every turn
+	EffectPoison EffectKind = iota
+	// EffectBurning does Magnitude fire damage every turn, and makes the Mob
+	// glow
+	EffectBurning
+	// EffectHaste speeds the Mob up by Magnitude percent
+	EffectHaste
+	// EffectBlind stops the Mob seeing anything
+	EffectBlind
+	// EffectMight adds Magnitude to the Mob's accuracy
+	EffectMight
+	// EffectRegeneration heals Magnitude health every turn
+	EffectRegeneration
+)
+
+// StackRule is what happens when a Mob gets an effect it already has
+type StackRule uint
+
+const (
+	// StackRefresh keeps the longer duration and the stronger magnitude
+	StackRefresh StackRule = iota
+	// StackIntensity adds the magnitudes together, and keeps the longer
+	// duration
+	StackIntensity
+	// StackDuration adds the durations together, and keeps the stronger
+	// magnitude
+	StackDuration
+)
+
+type effectKindInfo struct {
+	name      string
+	adjective string
+	stacking  StackRule
+}
+
+var effectKinds = []effectKindInfo{
+	EffectPoison:       {"poison", "poisoned", StackIntensity},
+	EffectBurning:      {"burning", "burning", StackRefresh},
+	EffectHaste:        {"haste", "hasted", StackRefresh},
+	EffectBlind:        {"blind", "blind", StackDuration},
+	EffectMight:        {"might", "mighty", StackRefresh},
+	EffectRegeneration: {"regeneration", "regenerating", StackIntensity},
+}
+
+func (k EffectKind) String() string {
+	if int(k) < len(effectKinds) {
+		return effectKinds[k].name
+	}
+	return fmt.Sprintf("EffectKind(%d)", k)
+}
+
+// Adjective describes a Mob under the effect, e.g. "poisoned"
+func (k EffectKind) Adjective() string {
+	if int(k) < len(effectKinds) {
+		return effectKinds[k].adjective
+	}
+	return k.String()
+}
+
+// Stacking is the effect's StackRule
+func (k EffectKind) Stacking() StackRule {
+	if int(k) < len(effectKinds) {
+		return effectKinds[k].stacking
+	}
+	return StackRefresh
+}
+
+// ParseEffectKind returns the EffectKind called name
+func ParseEffectKind(name string) (EffectKind, error) {
+	names := make([]string, len(effectKinds))
+	for k, info := range effectKinds {
+		if info.name == name {
+			return EffectKind(k), nil
+		}
+		names[k] = info.name
+	}
+	return 0, fmt.Errorf("Unknown effect %q, want one of %s", name, strings.Join(names, ", "))
+}
+
+// An Effect is a temporary change to a Mob, lasting Turns game turns.
+type Effect struct {
+	Kind      EffectKind
+	Turns     uint
+	Magnitude int
+}
+
+func (e Effect) String() string {
+	return fmt.Sprintf("%s %d for %d turns", e.Kind, e.Magnitude, e.Turns)
+}
+
+// stack combines e with another Effect of the same kind, following the kind's
+// StackRule.
+func (e Effect) stack(other Effect) Effect {
+	switch e.Kind.Stacking() {
+	case StackIntensity:
+		e.Magnitude += other.Magnitude
+		if other.Turns > e.Turns {
+			e.Turns = other.Turns
+		}
+	case StackDuration:
+		e.Turns += other.Turns
+		if other.Magnitude > e.Magnitude {
+			e.Magnitude = other.Magnitude
+		}
+	default:
+		if other.Turns > e.Turns {
+			e.Turns = other.Turns
+		}
+		if other.Magnitude > e.Magnitude {
+			e.Magnitude = other.Magnitude
+		}
+	}
+	return e
+}
+
+// EffectTick is what a turn's worth of effects did to a Mob.
+type EffectTick struct {
+	// Damage is how much damage the Mob took, after resistances
+	Damage uint
+	// Healed is how much health the Mob regained
+	Healed uint
+	// Expired are the effects that wore off
+	Expired []Effect
+}
+
+// BurningLightRadius is how far a burning Mob lights up around it
+const BurningLightRadius = 3
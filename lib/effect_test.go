@@ -0,0 +1,80 @@
+package gorl
+
+import (
+	"io/ioutil"
+	"log"
+	"testing"
+)
+
+func TestEffectStacking(t *testing.T) {
+	tests := []struct {
+		first, second, want Effect
+	}{
+		{Effect{EffectPoison, 3, 1}, Effect{EffectPoison, 5, 2}, Effect{EffectPoison, 5, 3}},
+		{Effect{EffectBurning, 3, 2}, Effect{EffectBurning, 5, 1}, Effect{EffectBurning, 5, 2}},
+		{Effect{EffectBlind, 3, 0}, Effect{EffectBlind, 5, 0}, Effect{EffectBlind, 8, 0}},
+		{Effect{EffectHaste, 10, 50}, Effect{EffectHaste, 2, 100}, Effect{EffectHaste, 10, 100}},
+	}
+	logger := log.New(ioutil.Discard, "", 0)
+	for _, test := range tests {
+		m := NewMob("mob", 'm', logger, nil)
+		m.AddEffect(test.first)
+		m.AddEffect(test.second)
+		if effects := m.Effects(); len(effects) != 1 || effects[0] != test.want {
+			t.Errorf("%s then %s gave %v, want %s", test.first, test.second, effects, test.want)
+		}
+	}
+}
+
+func TestTickEffects(t *testing.T) {
+	logger := log.New(ioutil.Discard, "", 0)
+	m := NewMob("mob", 'm', logger, nil)
+	m.SetCorpse(nil)
+	m.SetMaxHealth(20)
+	m.SetResistance(DamageFire, 50)
+	m.AddEffect(Effect{EffectPoison, 2, 3})
+	m.AddEffect(Effect{EffectBurning, 1, 4})
+	m.AddEffect(Effect{EffectRegeneration, 3, 5})
+
+	tick := m.TickEffects()
+	if tick.Damage != 5 || tick.Healed != 0 || m.Health() != 15 {
+		t.Errorf("First tick: %+v, health %d, want 5 damage, nothing healed and 15 health", tick, m.Health())
+	}
+	if len(tick.Expired) != 1 || tick.Expired[0].Kind != EffectBurning {
+		t.Errorf("First tick expired %v, want just burning", tick.Expired)
+	}
+	tick = m.TickEffects()
+	if tick.Damage != 3 || tick.Healed != 5 || m.Health() != 17 {
+		t.Errorf("Second tick: %+v, health %d, want 3 damage, 5 healed and 17 health", tick, m.Health())
+	}
+	tick = m.TickEffects()
+	if tick.Healed != 3 || m.Health() != 20 {
+		t.Errorf("Third tick healed %d to %d health, want 3 to 20", tick.Healed, m.Health())
+	}
+	if len(m.Effects()) != 0 {
+		t.Errorf("Effects left after they should have worn off: %v", m.Effects())
+	}
+}
+
+func TestEffectModifiers(t *testing.T) {
+	logger := log.New(ioutil.Discard, "", 0)
+	m := NewMob("mob", 'm', logger, nil)
+	m.SetVisionRadius(10)
+	m.AddEffect(Effect{EffectHaste, 5, 50})
+	m.AddEffect(Effect{EffectBlind, 5, 0})
+	m.AddEffect(Effect{EffectMight, 5, 2})
+	m.AddEffect(Effect{EffectBurning, 5, 0})
+
+	if want := uint(MobDefaultSpeed * 3 / 2); m.Speed() != want {
+		t.Errorf("Hasted speed is %d, want %d", m.Speed(), want)
+	}
+	if m.VisionRadius() != 0 {
+		t.Errorf("Blind vision radius is %d, want 0", m.VisionRadius())
+	}
+	if m.Accuracy() != 2 {
+		t.Errorf("Mighty accuracy is %d, want 2", m.Accuracy())
+	}
+	if m.LightRadius() != BurningLightRadius {
+		t.Errorf("Burning light radius is %d, want %d", m.LightRadius(), BurningLightRadius)
+	}
+}
@@ -21,6 +21,21 @@ type Feature interface {
 
 	LightRadius() int
 	SetLightRadius(int)
+	LightColor() Light
+	SetLightColor(Light)
+}
+
+func init() {
+	RegisterFeatureType("feature", &feature{}, featureRecord{},
+		func(f Feature, s *Saver) (interface{}, error) {
+			return f.(*feature).record(), nil
+		},
+		func(record interface{}, l *Loader) (Feature, error) {
+			f := &feature{}
+			f.restore(record.(featureRecord))
+			return f, nil
+		},
+	)
 }
 
 type feature struct {
@@ -30,6 +45,7 @@ type feature struct {
 	color       termbox.Attribute
 	flags       Flag
 	lightRadius int
+	lightColor  Light
 }
 
 // NewFeature returns a new Feature
@@ -38,6 +54,7 @@ func NewFeature(name string, char rune) Feature {
 	f.name = name
 	f.char = char
 	f.color = termbox.ColorDefault
+	f.lightColor = WhiteLight
 	return f
 }
 
@@ -69,6 +86,15 @@ func (f *feature) Flags() Flag {
 	return f.flags
 }
 
+// SetBlocksLight sets whether the Feature casts a shadow
+func (f *feature) SetBlocksLight(blocks bool) {
+	if blocks {
+		f.flags |= FlagBlocksLight
+	} else {
+		f.flags &= ^FlagBlocksLight
+	}
+}
+
 func (f *feature) LightRadius() int {
 	return f.lightRadius
 }
@@ -77,6 +103,15 @@ func (f *feature) SetLightRadius(radius int) {
 	f.lightRadius = radius
 }
 
+// LightColor is the color of the light the Feature gives off
+func (f *feature) LightColor() Light {
+	return f.lightColor
+}
+
+func (f *feature) SetLightColor(color Light) {
+	f.lightColor = color
+}
+
 func (f *feature) String() string {
 	return fmt.Sprintf(
 		"<feature %s char:%c, loc:%s, flags:%s, lightRadius:%d>",
@@ -87,3 +122,36 @@ func (f *feature) String() string {
 		f.lightRadius,
 	)
 }
+
+// featureRecord is the saved form of a feature
+type featureRecord struct {
+	Name        string
+	Char        rune
+	Color       termbox.Attribute
+	Flags       Flag
+	LightRadius int
+	LightColor  Light
+	Loc         vectorRecord
+}
+
+func (f *feature) record() featureRecord {
+	return featureRecord{
+		f.name,
+		f.char,
+		f.color,
+		f.flags,
+		f.lightRadius,
+		f.lightColor,
+		newVectorRecord(f.loc),
+	}
+}
+
+func (f *feature) restore(r featureRecord) {
+	f.name = r.Name
+	f.char = r.Char
+	f.color = r.Color
+	f.flags = r.Flags
+	f.lightRadius = r.LightRadius
+	f.lightColor = r.LightColor
+	f.loc = r.Loc.vector()
+}
@@ -4,9 +4,8 @@ import (
 	"fmt"
 	"log"
 	"math/rand"
+	"sort"
 	"time"
-
-	"github.com/nsf/termbox-go"
 )
 
 // GameState represents the state of the Game engine
@@ -17,7 +16,8 @@ const (
 	GameInvalidState GameState = iota
 	// GamePlayerTurn is when waiting on the player to make an action
 	GamePlayerTurn
-	// GameWorldTurn is when the AI and world objects get to act
+	// GameWorldTurn is when the AI and world objects act, until the player is
+	// next to act
 	GameWorldTurn
 	// GameClosed is when the game is done, and will shut down
 	GameClosed
@@ -32,13 +32,58 @@ const (
 	ActDrop // target is an Item to drop
 	ActDropAll
 	ActPickUpAll
+	ActTravel // target is a Vector destination, reached one step at a time
+	ActExplore
+	ActDescend
+	ActAscend
+	ActEquip   // target is a Wearable to put on
+	ActUnequip // target is the uint index of the wear slot to take off
+	ActUse     // target is a Usable to use up
+	ActFire    // target is the Vector to fire the wielded ranged weapon at
+	ActThrow   // target is a ThrowTarget
+	ActOpen    // target is the Vector direction of the Door to open
+	ActClose   // target is the Vector direction of the Door to close
 )
 
+// EnergyPerTurn is how much energy an ordinary action costs, and so how long a
+// game turn lasts.
+const EnergyPerTurn = 100
+
+// actionCosts is how much energy each action costs to perform
+var actionCosts = map[mobAction]uint{
+	ActNone:      0,
+	ActWait:      EnergyPerTurn,
+	ActMove:      EnergyPerTurn,
+	ActDrop:      EnergyPerTurn / 2,
+	ActDropAll:   EnergyPerTurn,
+	ActPickUpAll: EnergyPerTurn * 2,
+	ActTravel:    EnergyPerTurn,
+	ActExplore:   EnergyPerTurn,
+	ActDescend:   EnergyPerTurn,
+	ActAscend:    EnergyPerTurn,
+	ActEquip:     EnergyPerTurn,
+	ActUnequip:   EnergyPerTurn,
+	ActUse:       EnergyPerTurn,
+	ActFire:      EnergyPerTurn,
+	ActThrow:     EnergyPerTurn,
+	ActOpen:      EnergyPerTurn,
+	ActClose:     EnergyPerTurn,
+}
+
 type MobAction struct {
 	action mobAction
 	target interface{}
 }
 
+func (a MobAction) String() string {
+	return fmt.Sprintf("<MobAction %s target:%v>", a.action, a.target)
+}
+
+// Cost returns the energy it takes to perform the action
+func (a MobAction) Cost() uint {
+	return actionCosts[a.action]
+}
+
 func (a mobAction) String() string {
 	switch a {
 	case ActNone:
@@ -47,10 +92,34 @@ func (a mobAction) String() string {
 		return "ActWait"
 	case ActMove:
 		return "ActMove"
+	case ActDrop:
+		return "ActDrop"
 	case ActDropAll:
 		return "ActDropAll"
 	case ActPickUpAll:
 		return "ActPickUpAll"
+	case ActTravel:
+		return "ActTravel"
+	case ActExplore:
+		return "ActExplore"
+	case ActDescend:
+		return "ActDescend"
+	case ActAscend:
+		return "ActAscend"
+	case ActEquip:
+		return "ActEquip"
+	case ActUnequip:
+		return "ActUnequip"
+	case ActUse:
+		return "ActUse"
+	case ActFire:
+		return "ActFire"
+	case ActThrow:
+		return "ActThrow"
+	case ActOpen:
+		return "ActOpen"
+	case ActClose:
+		return "ActClose"
 	default:
 		return fmt.Sprintf("mobAction(%d)", a)
 	}
@@ -74,92 +143,121 @@ func (s GameState) String() string {
 // Game is the entry type to GoRL. Manages the UI, dungeons, player, etc.
 type Game struct {
 	ui             UI
+	config         GameConfig
 	messages       []string
 	player         Player
 	dungeons       []*Dungeon
 	currentDungeon *Dungeon
 	state          GameState
+	autoAction     MobAction
 	turn           uint
 	log            *log.Logger
+	seed           int64
+	width, height  int
+	rng            *rngSource
 	dice           *rand.Rand
 }
 
-// NewGame initializes and returns a new Game. Or an error. You should check that.
-// Please `defer game.Close()`.
-func NewGame(log *log.Logger, dice *rand.Rand) (*Game, error) {
-	game := &Game{}
-	game.dice = dice
-	game.log = log
-	game.messages = make([]string, 0, 10)
-	game.turn = 0
-
-	dungeon := GenerateDungeon(log, dice)
-	game.dungeons = make([]*Dungeon, 0, 10)
-	game.dungeons = append(game.dungeons, dungeon)
+// NewGame initializes and returns a new Game set up as config says. Or an
+// error. You should check that. Please `defer game.Close()`.
+func NewGame(log *log.Logger, config GameConfig) (*Game, error) {
+	if err := config.validate(); err != nil {
+		return nil, err
+	}
+	game := newGame(log, config)
+	dungeon := game.dungeonAt(0)
 
 	game.player = NewPlayer(game.log, dungeon)
-	game.player.SetLoc(dungeon.origin)
-
-	torch := NewItem("bright torch", '!', 1)
-	torch.SetLightRadius(20)
-	game.player.AddToInventory(torch)
-
-	sword := NewWeapon("sword", ']', 5, 10)
-	game.player.AddToInventory(sword)
-	game.player.Wield(sword, 0)
-
+	start, _ := dungeon.FreeLocNear(dungeon.origin)
+	game.player.SetLoc(start)
+	if config.StartingKit != nil {
+		config.StartingKit(game, game.player)
+	}
 	dungeon.AddMob(game.player)
 
-	for i := 0; i < 10; i++ {
-		dest := Vector{game.dice.Intn(dungeon.width), game.dice.Intn(dungeon.height)}
-		for !(dungeon.Tile(dest).Crossable() && dungeon.FeatureGroup(dest).Crossable()) {
-			dest = Vector{game.dice.Intn(dungeon.width), game.dice.Intn(dungeon.height)}
-		}
-		mob := NewMob(fmt.Sprintf("orc #%d", i), 'o', game.log, dungeon)
-		mob.SetVisionRadius(100)
-		mob.SetColor(termbox.ColorGreen)
-		mob.SetLoc(dest)
-
-		torch := NewItem("torch", '!', 1)
-		torch.SetLightRadius(10)
-		mob.AddToInventory(torch)
-
-		dungeon.AddMob(mob)
+	if err := game.start(dungeon); err != nil {
+		return nil, err
 	}
+	game.AddMessage("Welcome to GoRL!")
+	game.AddMessage(fmt.Sprintf("Your seed is %d.", config.Seed))
+	return game, nil
+}
+
+// newGame returns a Game with no dungeons, player or UI.
+func newGame(log *log.Logger, config GameConfig) *Game {
+	game := &Game{}
+	game.config = config
+	game.seed = config.Seed
+	game.width = config.Width
+	game.height = config.Height
+	game.rng = newRNGSource(config.Seed)
+	game.dice = rand.New(game.rng)
+	game.log = log
+	game.messages = make([]string, 0, 10)
+	game.turn = 0
+	game.autoAction = MobAction{ActNone, nil}
+	game.dungeons = make([]*Dungeon, 0, 10)
+	return game
+}
 
-	ui, err := NewTermboxUI(game)
+// start opens the Game's UI, with the Player on dungeon, ready for their
+// turn.
+func (game *Game) start(dungeon *Dungeon) error {
+	ui, err := game.config.NewUI(game)
 	if err != nil {
-		return nil, err
+		return err
 	}
 	game.ui = ui
 	game.SetDungeon(dungeon)
+	game.ui.PointCameraAt(dungeon, game.player.Loc())
 	game.updatePlayerFOV()
-
-	game.AddMessage("Welcome to GoRL!")
 	game.state = GamePlayerTurn
-	return game, nil
+	return nil
+}
+
+// dungeonAt returns the Dungeon at depth, generating and populating it if
+// nobody has been there yet.
+func (game *Game) dungeonAt(depth int) *Dungeon {
+	for len(game.dungeons) <= depth {
+		dungeon := game.config.Generator.Generate(game.log, game.dice, game.width, game.height, len(game.dungeons))
+		if game.config.Populate != nil {
+			game.config.Populate(game, dungeon)
+		}
+		game.dungeons = append(game.dungeons, dungeon)
+	}
+	return game.dungeons[depth]
 }
 
 func (game *Game) updatePlayerFOV() {
 	game.currentDungeon.ResetFlag(FlagLit | FlagVisible)
 	game.currentDungeon.CalculateLighting()
+	// A blind Player can't see a thing, but still knows where they're
+	// standing
+	if game.player.HasEffect(EffectBlind) {
+		game.currentDungeon.Tile(game.player.Loc()).flags |= FlagVisible | FlagSeen
+		game.currentDungeon.Remember(game.player.Loc())
+		return
+	}
 	game.currentDungeon.OnTilesInLineOfSight(game.player.Loc(), game.player.VisionRadius(), func(t *Tile, loc Vector) {
 		if t.Lit() {
 			t.flags |= FlagVisible | FlagSeen
+			game.currentDungeon.Remember(loc)
 		}
 	})
 }
 
 // MoveOrAct calculates the destination tile based on the movement parameter and
 // the Player's location, and then
-//   * if there is a mob on the destination, attacks the mob and returns true
-//   * if not and destination is Crossable, moves the player there and returns true
-//   * if the destination is not Crossable, returns false
+//   - if there is a mob on the destination, attacks the mob and returns true
+//   - if there is a closed door there, opens it, with a key from the mob's
+//     inventory if it's locked, and returns whether it opened
+//   - if not and destination is Crossable, moves the player there and returns true
+//   - if the destination is not Crossable, returns false
 func (game *Game) MoveOrAct(mob Mob, movement Vector) bool {
 	game.log.Printf("%s MoveOrAct'ing %s", mob, movement)
 	destination := mob.Loc().Add(movement)
 	if otherMob := game.currentDungeon.MobAt(destination); otherMob != nil {
-		if damageDealt, ok := mob.Attack(otherMob); ok {
+		if result, ok := mob.Attack(otherMob, game.dice); ok {
 			// TODO: Different messages for various visibility combinations?
 			// e.g.
 			// mob otherMob message
@@ -167,13 +265,12 @@ func (game *Game) MoveOrAct(mob Mob, movement Vector) bool {
 			// 0   1        "Something attacks otherMob"
 			// 1   0        "Mob attacks something"
 			// 1   1        "Mob attacks otherMob"
-			game.EmitMessage(mob.Loc(), fmt.Sprintf("%s hit %s for %d damage", mob.Name(), otherMob.Name(), damageDealt))
-			if otherMob.Dead() {
-				game.EmitMessage(otherMob.Loc(), fmt.Sprintf("The %s dies!", otherMob.Name()))
-			}
+			game.reportAttack(mob.Loc(), mob, otherMob, result)
 			return true
 		}
 		return false
+	} else if door, ok := game.currentDungeon.FeatureAt(destination).(Door); ok && door.State() != DoorOpen {
+		return game.openDoor(mob, door)
 	} else if moved := game.currentDungeon.MoveMob(mob, movement); !moved {
 		return moved
 	}
@@ -181,6 +278,123 @@ func (game *Game) MoveOrAct(mob Mob, movement Vector) bool {
 	return true
 }
 
+// openDoor has mob open door, unlocking it first with a Key from their
+// inventory if they need to.
+func (game *Game) openDoor(mob Mob, door Door) bool {
+	switch door.State() {
+	case DoorOpen:
+		game.EmitMessage(door.Loc(), fmt.Sprintf("The %s is already open", door.Name()))
+		return false
+	case DoorLocked:
+		var fits Key
+		for _, item := range mob.Inventory() {
+			if key, ok := item.(Key); ok && key.Fits(door) {
+				fits = key
+				break
+			}
+		}
+		if fits == nil {
+			game.EmitMessage(door.Loc(), fmt.Sprintf("%s found the door locked", mob.Name()))
+			return false
+		}
+		game.EmitMessage(door.Loc(), fmt.Sprintf("%s unlocked the door with %s", mob.Name(), fits.Name()))
+	}
+	door.SetState(DoorOpen)
+	game.EmitMessage(door.Loc(), fmt.Sprintf("%s opened the door", mob.Name()))
+	game.ui.MarkDirty()
+	return true
+}
+
+// closeDoor has mob close door, if there's nothing in the doorway.
+func (game *Game) closeDoor(mob Mob, door Door) bool {
+	if door.State() != DoorOpen {
+		game.EmitMessage(door.Loc(), fmt.Sprintf("The %s is already closed", door.Name()))
+		return false
+	}
+	fg := game.currentDungeon.featuresAt(door.Loc())
+	if fg.mob != nil || len(fg.items) > 0 {
+		game.EmitMessage(door.Loc(), fmt.Sprintf("Something is in the way of the %s", door.Name()))
+		return false
+	}
+	door.SetState(DoorClosed)
+	game.EmitMessage(door.Loc(), fmt.Sprintf("%s closed the door", mob.Name()))
+	game.ui.MarkDirty()
+	return true
+}
+
+// reportAttack emits messages about the result of attacker attacking
+// defender from origin.
+func (game *Game) reportAttack(origin Vector, attacker Feature, defender Mob, result AttackResult) {
+	game.EmitMessage(origin, attackMessage(attacker, defender, result))
+	for _, effect := range result.Effects {
+		game.EmitMessage(defender.Loc(), fmt.Sprintf("%s is %s!", defender.Name(), effect.Kind.Adjective()))
+	}
+	if defender.Dead() {
+		game.EmitMessage(defender.Loc(), fmt.Sprintf("The %s dies!", defender.Name()))
+	}
+}
+
+// fire fires mob's ranged weapon at target, attacking the first Mob in the
+// way.
+func (game *Game) fire(mob Mob, target Vector) bool {
+	weapon, ok := RangedWeapon(mob)
+	if !ok {
+		game.EmitMessage(mob.Loc(), fmt.Sprintf("%s has nothing to fire", mob.Name()))
+		return false
+	}
+	if target == mob.Loc() {
+		return false
+	}
+	game.EmitMessage(mob.Loc(), fmt.Sprintf("%s fired %s", mob.Name(), weapon.Name()))
+	if _, hit := mob.Dungeon().ProjectilePath(mob.Loc(), target, weapon.Range()); hit != nil {
+		if result, ok := mob.Attack(hit, game.dice); ok {
+			game.reportAttack(mob.Loc(), mob, hit, result)
+		}
+	}
+	return true
+}
+
+// throw throws item from mob's inventory at target. It attacks the first Mob
+// in the way, and lands wherever it stops.
+func (game *Game) throw(mob Mob, item Item, target Vector) bool {
+	if target == mob.Loc() {
+		return false
+	}
+	if !mob.RemoveFromInventory(item) {
+		game.log.Panicf("%s tried to throw %s, which they don't have", mob, item)
+		return false
+	}
+	game.EmitMessage(mob.Loc(), fmt.Sprintf("%s threw %s", mob.Name(), item.Name()))
+	dungeon := mob.Dungeon()
+	path, hit := dungeon.ProjectilePath(mob.Loc(), target, ThrowRange)
+	landed := mob.Loc()
+	if len(path) > 0 {
+		landed = path[len(path)-1]
+	}
+	if hit != nil {
+		if result, ok := (thrownAttack{mob, item}).Attack(hit, game.dice); ok {
+			game.reportAttack(mob.Loc(), item, hit, result)
+		}
+	}
+	item.SetLoc(landed)
+	dungeon.AddItem(item)
+	return true
+}
+
+// attackMessage describes the result of attacker attacking defender
+func attackMessage(attacker, defender Feature, result AttackResult) string {
+	switch {
+	case !result.Hit && result.Critical:
+		return fmt.Sprintf("%s fumbled their attack on %s", attacker.Name(), defender.Name())
+	case !result.Hit:
+		return fmt.Sprintf("%s missed %s", attacker.Name(), defender.Name())
+	case result.Critical:
+		return fmt.Sprintf("%s critically hit %s for %d damage!", attacker.Name(), defender.Name(), result.Damage)
+	default:
+		return fmt.Sprintf("%s hit %s for %d damage", attacker.Name(), defender.Name(), result.Damage)
+	}
+}
+
 func (game *Game) EmitMessage(origin Vector, message string) {
 	if game.currentDungeon.Tile(origin).Visible() {
 		game.AddMessage(message)
@@ -222,13 +436,23 @@ mainLoop:
 			game.WorldTick()
 			nextState = GamePlayerTurn
 		case GamePlayerTurn:
-			action, nextState = game.ui.DoEvent()
+			if game.autoAction.action != ActNone && !game.hostilesInView() && !game.player.HasEffect(EffectBlind) {
+				action, nextState = game.autoAction, GameWorldTurn
+			} else {
+				game.autoAction = MobAction{ActNone, nil}
+				action, nextState = game.ui.DoEvent()
+			}
 			if nextState != GameClosed {
 				if game.doMobAction(game.player, action) {
+					game.currentDungeon.scheduler.Spend(game.player, action.Cost())
 					game.ui.PointCameraAt(game.currentDungeon, game.player.Loc())
 					game.updatePlayerFOV()
 					game.ui.MarkDirty()
+					if action.action == ActTravel || action.action == ActExplore {
+						game.autoAction = action
+					}
 				} else {
+					game.autoAction = MobAction{ActNone, nil}
 					nextState = GamePlayerTurn
 				}
 			}
@@ -280,26 +504,275 @@ func (game *Game) doMobAction(mob Mob, action MobAction) bool {
 	case ActMove:
 		direction := action.target.(Vector)
 		return game.MoveOrAct(mob, direction)
+	case ActTravel, ActExplore:
+		return game.travel(mob, action)
+	case ActDescend:
+		return game.takeStairs(mob, true)
+	case ActAscend:
+		return game.takeStairs(mob, false)
+	case ActEquip:
+		armor, ok := action.target.(Wearable)
+		if !ok {
+			game.EmitMessage(mob.Loc(), fmt.Sprintf("%s can't wear %s", mob.Name(), action.target.(Item).Name()))
+			return false
+		}
+		if !mob.Equip(armor) {
+			game.EmitMessage(mob.Loc(), fmt.Sprintf("%s is already wearing something on their %s", mob.Name(), armor.Slot()))
+			return false
+		}
+		game.EmitMessage(mob.Loc(), fmt.Sprintf("%s put on %s", mob.Name(), armor.Name()))
+		return true
+	case ActUnequip:
+		slot := action.target.(uint)
+		if int(slot) >= len(mob.Wearing()) || mob.Wearing()[slot] == nil {
+			game.EmitMessage(mob.Loc(), fmt.Sprintf("%s isn't wearing anything there", mob.Name()))
+			return false
+		}
+		armor := mob.Wearing()[slot]
+		mob.Unequip(slot)
+		game.EmitMessage(mob.Loc(), fmt.Sprintf("%s took off %s", mob.Name(), armor.Name()))
+		return true
+	case ActUse:
+		usable, ok := action.target.(Usable)
+		if !ok {
+			game.EmitMessage(mob.Loc(), fmt.Sprintf("%s can't use %s", mob.Name(), action.target.(Item).Name()))
+			return false
+		}
+		if !mob.RemoveFromInventory(usable) {
+			game.log.Panicf("%s tried to use %s, which they don't have", mob, usable)
+			return false
+		}
+		game.EmitMessage(mob.Loc(), fmt.Sprintf("%s %s %s", mob.Name(), usable.Kind().PastTense(), usable.Name()))
+		game.useItem(mob, usable)
+		return true
+	case ActFire:
+		return game.fire(mob, action.target.(Vector))
+	case ActThrow:
+		target := action.target.(ThrowTarget)
+		return game.throw(mob, target.Item, target.At)
+	case ActOpen, ActClose:
+		loc := mob.Loc().Add(action.target.(Vector))
+		door, ok := game.currentDungeon.FeatureAt(loc).(Door)
+		if !ok {
+			game.EmitMessage(mob.Loc(), fmt.Sprintf("%s found no door there", mob.Name()))
+			return false
+		}
+		if action.action == ActOpen {
+			return game.openDoor(mob, door)
+		}
+		return game.closeDoor(mob, door)
 	case ActNone:
 		return false
 	default:
 		log.Panicf("Bad action: %s, %s", mob, action)
 		return false
 	}
-	return false
 }
 
-// WorldTick runs a single turn of the game engine
+// useItem does everything usable does to mob.
+func (game *Game) useItem(mob Mob, usable Usable) {
+	dungeon := mob.Dungeon()
+	for _, use := range usable.Uses() {
+		switch use.Kind {
+		case UseHeal:
+			if use.Magnitude <= 0 {
+				continue
+			}
+			healed := mob.Heal(uint(use.Magnitude))
+			game.EmitMessage(mob.Loc(), fmt.Sprintf("%s healed %d health", mob.Name(), healed))
+		case UseLight:
+			if mob.LightRadius() >= use.Magnitude {
+				game.EmitMessage(mob.Loc(), fmt.Sprintf("%s flickers for a moment", mob.Name()))
+				continue
+			}
+			mob.SetLightRadius(use.Magnitude)
+			game.EmitMessage(mob.Loc(), fmt.Sprintf("%s starts to glow", mob.Name()))
+		case UseTeleport:
+			loc, ok := dungeon.RandomFreeLoc(game.dice)
+			if !ok {
+				game.EmitMessage(mob.Loc(), fmt.Sprintf("%s flickers for a moment", mob.Name()))
+				continue
+			}
+			game.EmitMessage(mob.Loc(), fmt.Sprintf("%s vanishes!", mob.Name()))
+			dungeon.PlaceMob(mob, loc)
+			game.EmitMessage(mob.Loc(), fmt.Sprintf("%s appears out of nowhere!", mob.Name()))
+		case UseMapping:
+			if mob != game.player {
+				continue
+			}
+			dungeon.SetFlag(FlagSeen)
+			game.EmitMessage(mob.Loc(), "A map of the level appears in your mind")
+		default:
+			game.log.Panicf("%s has a bad use: %v", usable, use)
+		}
+	}
+	for _, effect := range usable.Effects() {
+		mob.AddEffect(effect)
+		game.EmitMessage(mob.Loc(), fmt.Sprintf("%s is %s!", mob.Name(), effect.Kind.Adjective()))
+	}
+	game.ui.MarkDirty()
+}
+
+// PlayerPathOptions are used when the Player travels. The Player only knows
+// the way across Tiles they've seen, and won't path through other Mobs. Doors
+// in the way are opened by bumping into them.
+var PlayerPathOptions = PathOptions{KnownOnly: true, OpenDoors: true}
+
+// travel moves mob a single step towards an ActTravel destination, or towards
+// the nearest unexplored area for ActExplore. Returns false if there's nowhere
+// left to go.
+func (game *Game) travel(mob Mob, action MobAction) bool {
+	var (
+		step Vector
+		ok   bool
+	)
+	switch action.action {
+	case ActTravel:
+		var path []Vector
+		path, ok = game.currentDungeon.FindPath(mob.Loc(), action.target.(Vector), PlayerPathOptions)
+		if !ok {
+			game.EmitMessage(mob.Loc(), fmt.Sprintf("%s doesn't know the way there.", mob.Name()))
+		}
+		ok = ok && len(path) > 0
+		if ok {
+			step = path[0]
+		}
+	case ActExplore:
+		var frontier []Vector
+		for _, loc := range game.currentDungeon.Frontier() {
+			if loc != mob.Loc() {
+				frontier = append(frontier, loc)
+			}
+		}
+		step, ok = game.currentDungeon.DijkstraMap(frontier, PlayerPathOptions).Downhill(mob.Loc())
+		if !ok {
+			game.EmitMessage(mob.Loc(), "There's nothing left to explore.")
+		}
+	}
+	if !ok {
+		return false
+	}
+	return game.MoveOrAct(mob, step.Sub(mob.Loc()))
+}
+
+// takeStairs moves mob up or down a level, if it is standing on the right
+// kind of Stairs. Any Mobs right next to the Player follow them.
+func (game *Game) takeStairs(mob Mob, down bool) bool {
+	from := mob.Dungeon()
+	stairs, ok := from.FeatureAt(mob.Loc()).(Stairs)
+	if !ok || stairs.Down() != down {
+		if down {
+			game.EmitMessage(mob.Loc(), fmt.Sprintf("%s can't go down here.", mob.Name()))
+		} else {
+			game.EmitMessage(mob.Loc(), fmt.Sprintf("%s can't go up here.", mob.Name()))
+		}
+		return false
+	}
+
+	var (
+		to      *Dungeon
+		arrival Vector
+	)
+	if down {
+		to = game.dungeonAt(from.Depth() + 1)
+		arrival = to.upStairs
+	} else {
+		to = game.dungeonAt(from.Depth() - 1)
+		arrival = to.downStairs
+	}
+
+	var followers []Mob
+	if mob == game.player {
+		for _, other := range from.Mobs() {
+			if other != mob && !other.Dead() && other.Loc().Sub(mob.Loc()).Distance() == 1 {
+				followers = append(followers, other)
+			}
+		}
+	}
+
+	to.scheduler.Sync(from.scheduler.Now())
+	game.moveMobToDungeon(mob, to, arrival)
+	for _, follower := range followers {
+		game.moveMobToDungeon(follower, to, arrival)
+		game.log.Printf("%s followed %s to depth %d", follower, mob, to.Depth())
+	}
+	if mob == game.player {
+		game.SetDungeon(to)
+		if down {
+			game.AddMessage(fmt.Sprintf("You descend to depth %d.", to.Depth()))
+		} else {
+			game.AddMessage(fmt.Sprintf("You climb up to depth %d.", to.Depth()))
+		}
+	}
+	return true
+}
+
+// moveMobToDungeon takes mob out of whichever Dungeon it is in, and puts it
+// in to as close to loc as possible.
+func (game *Game) moveMobToDungeon(mob Mob, to *Dungeon, loc Vector) {
+	mob.Dungeon().DeleteMob(mob)
+	dest, ok := to.FreeLocNear(loc)
+	if !ok {
+		game.log.Panicf("No room for %s at depth %d", mob, to.Depth())
+	}
+	mob.SetLoc(dest)
+	to.AddMob(mob)
+}
+
+// hostilesInView returns true if the Player can see any other Mob.
+func (game *Game) hostilesInView() bool {
+	return len(game.VisibleHostiles()) > 0
+}
+
+// VisibleHostiles returns every other Mob the Player can see, nearest first.
+func (game *Game) VisibleHostiles() []Mob {
+	var hostiles []Mob
+	for _, mob := range game.currentDungeon.Mobs() {
+		if mob != game.player && game.currentDungeon.Tile(mob.Loc()).Visible() {
+			hostiles = append(hostiles, mob)
+		}
+	}
+	origin := game.player.Loc()
+	sort.SliceStable(hostiles, func(i, j int) bool {
+		return hostiles[i].Loc().Sub(origin).Distance() < hostiles[j].Loc().Sub(origin).Distance()
+	})
+	return hostiles
+}
+
+// WorldTick runs every Mob's turns, in the order the Dungeon's scheduler
+// dictates, until it is the Player's turn to act again.
 func (game *Game) WorldTick() {
 	tickStartTime := time.Now()
-	game.turn++
-	game.log.Printf("Game tick: %d", game.turn)
 	changed := false
-	var mobAction MobAction
-	for _, mob := range game.currentDungeon.Mobs() {
+	var (
+		mob       Mob
+		mobAction MobAction
+		acted     bool
+	)
+	scheduler := game.currentDungeon.scheduler
+	for !game.player.Dead() {
+		mob = scheduler.Next()
+		if mob == nil || mob == game.player {
+			break
+		}
+		game.advanceTurn(scheduler.Now() / EnergyPerTurn)
+		if mob.Dead() {
+			// Killed by its effects, waiting to be reaped
+			continue
+		}
 		mobAction = mob.Tick(game.turn, game.dice)
-		changed = game.doMobAction(mob, mobAction) || changed
+		acted = game.doMobAction(mob, mobAction)
+		if !acted {
+			// A Mob that can't do what it wants still loses its turn
+			mobAction = MobAction{ActWait, nil}
+		}
+		scheduler.Spend(mob, mobAction.Cost())
+		changed = acted || changed
+		game.currentDungeon.ReapDead()
 	}
+	game.advanceTurn(scheduler.Now() / EnergyPerTurn)
+	game.currentDungeon.ReapDead()
+	game.log.Printf("Game turn: %d (%s)", game.turn, scheduler)
 	if changed {
 		game.ui.MarkDirty()
 	}
@@ -309,6 +782,68 @@ func (game *Game) WorldTick() {
 	game.log.Printf("Tick took %v to run", tickRunTime)
 }
 
+// Content returns the monsters and items the Game was configured with.
+func (game *Game) Content() *Content {
+	return game.config.Content
+}
+
+// advanceTurn moves the Game on to turn, running every turn's worth of
+// effects on the Mobs in the current Dungeon on the way.
+func (game *Game) advanceTurn(turn uint) {
+	for game.turn < turn {
+		game.turn++
+		for _, mob := range game.currentDungeon.Mobs() {
+			game.tickEffects(mob)
+		}
+		game.ui.MarkDirty()
+	}
+}
+
+// tickEffects runs a turn of mob's effects, and reports what they did.
+func (game *Game) tickEffects(mob Mob) {
+	if len(mob.Effects()) == 0 || mob.Dead() {
+		return
+	}
+	tick := mob.TickEffects()
+	if tick.Damage > 0 {
+		game.EmitMessage(mob.Loc(), fmt.Sprintf("%s took %d damage", mob.Name(), tick.Damage))
+	}
+	for _, effect := range tick.Expired {
+		game.EmitMessage(mob.Loc(), fmt.Sprintf("%s is no longer %s", mob.Name(), effect.Kind.Adjective()))
+	}
+	if mob.Dead() {
+		game.EmitMessage(mob.Loc(), fmt.Sprintf("The %s dies!", mob.Name()))
+	}
+}
+
+// Dice returns the Game's dice. Roll them for anything random, so that the
+// Game stays reproducible from its seed.
+func (game *Game) Dice() *rand.Rand {
+	return game.dice
+}
+
+// Seed returns the number the Game's dice were seeded with. The same seed and
+// the same player actions always make the same Game.
+func (game *Game) Seed() int64 {
+	return game.seed
+}
+
+// UI returns the Game's UI
+func (game *Game) UI() UI {
+	return game.ui
+}
+
+// SetUI replaces the Game's UI, e.g. with one wrapping the current UI.
+func (game *Game) SetUI(ui UI) {
+	game.ui = ui
+	game.ui.MarkDirty()
+}
+
+// Player returns the Game's Player
+func (game *Game) Player() Player {
+	return game.player
+}
+
 // Close cleans up after a Game.
 func (game *Game) Close() {
 	game.ui.Close()
@@ -0,0 +1,506 @@
+package gorl
+
+import (
+	"io/ioutil"
+	"log"
+	"math/rand"
+	"strings"
+	"testing"
+
+	"github.com/RWJMurphy/gorl/lib/roll"
+	"github.com/nsf/termbox-go"
+)
+
+// newTestGame builds a started Game from rows like newTestDungeon's, with the
+// Player at '@' and an orc at each 'o'. The Player's actions come from
+// actions.
+func newTestGame(t *testing.T, actions ActionSource, rows ...string) *Game {
+	d := newTestDungeon(rows...)
+	game := newGame(d.log, GameConfig{
+		Seed:   1,
+		Width:  d.width,
+		Height: d.height,
+		NewUI:  NewHeadlessUI(d.width, d.height, actions),
+	})
+	game.dungeons = append(game.dungeons, d)
+	for y, row := range rows {
+		for x, c := range row {
+			switch c {
+			case '@':
+				game.player = NewPlayer(d.log, d)
+				game.player.SetLoc(Vector{x, y})
+				d.AddMob(game.player)
+			case 'o':
+				orc := NewMob("orc", 'o', d.log, d)
+				orc.SetVisionRadius(10)
+				orc.SetLoc(Vector{x, y})
+				d.AddMob(orc)
+			}
+		}
+	}
+	if game.player == nil {
+		t.Fatal("Test map has no Player")
+	}
+	if err := game.start(d); err != nil {
+		t.Fatal(err)
+	}
+	return game
+}
+
+func TestMoveOrAct(t *testing.T) {
+	game := newTestGame(t, NewScriptedActions(),
+		"#####",
+		"#@.o#",
+		"#####",
+	)
+	player := game.Player()
+
+	if game.MoveOrAct(player, Vector{-1, 0}) {
+		t.Error("Player moved into a wall")
+	}
+	if !game.MoveOrAct(player, Vector{1, 0}) || player.Loc() != (Vector{2, 1}) {
+		t.Errorf("Player didn't move east, is at %s", player.Loc())
+	}
+
+	orc := game.currentDungeon.MobAt(Vector{3, 1})
+	if !game.MoveOrAct(player, Vector{1, 0}) {
+		t.Fatal("Player didn't attack the orc")
+	}
+	if player.Loc() != (Vector{2, 1}) {
+		t.Errorf("Player moved into the orc, is at %s", player.Loc())
+	}
+	if attacks := attacksBy("Player", game.messages); attacks != 1 {
+		t.Errorf("Player attacked %d times, want 1: %v", attacks, game.messages)
+	}
+	if orc.Health() > MobDefaultHealth {
+		t.Errorf("Orc has %d health, more than it started with", orc.Health())
+	}
+}
+
+// attacksBy counts the attacks by attacker reported in messages
+func attacksBy(attacker string, messages []string) int {
+	attacks := 0
+	for _, m := range messages {
+		for _, verb := range []string{" hit ", " critically hit ", " missed ", " fumbled "} {
+			if strings.Contains(m, ": "+attacker+verb) {
+				attacks++
+			}
+		}
+	}
+	return attacks
+}
+
+func TestDoMobActionItems(t *testing.T) {
+	game := newTestGame(t, NewScriptedActions(),
+		"#####",
+		"#.@.#",
+		"#####",
+	)
+	player := game.Player()
+
+	if game.doMobAction(player, MobAction{ActPickUpAll, nil}) {
+		t.Error("Picked up nothing")
+	}
+	torch := NewItem("torch", '!', 1)
+	torch.SetLoc(player.Loc())
+	game.currentDungeon.AddItem(torch)
+	if !game.doMobAction(player, MobAction{ActPickUpAll, nil}) {
+		t.Fatal("Couldn't pick up the torch")
+	}
+	if inventory := player.Inventory(); len(inventory) != 1 || inventory[0] != torch {
+		t.Errorf("Inventory is %v, want the torch", inventory)
+	}
+	if !game.doMobAction(player, MobAction{ActDrop, torch}) {
+		t.Fatal("Couldn't drop the torch")
+	}
+	if items := game.currentDungeon.ItemsAt(player.Loc()); len(items) != 1 || items[0] != torch {
+		t.Errorf("Items on the floor are %v, want the torch", items)
+	}
+}
+
+func TestWorldTickFastMob(t *testing.T) {
+	game := newTestGame(t, NewScriptedActions(),
+		"#####",
+		"#@o.#",
+		"#####",
+	)
+	player := game.Player()
+	orc := game.currentDungeon.MobAt(Vector{2, 1})
+	orc.SetSpeed(MobDefaultSpeed * 2)
+
+	game.currentDungeon.scheduler.Spend(player, EnergyPerTurn)
+	game.WorldTick()
+
+	if attacks := attacksBy("orc", game.messages); attacks != 2 {
+		t.Errorf("Fast orc attacked %d times in a turn, want 2: %v", attacks, game.messages)
+	}
+	if game.turn != 1 {
+		t.Errorf("Turn is %d, want 1", game.turn)
+	}
+}
+
+func TestRunScripted(t *testing.T) {
+	east := MobAction{ActMove, Vector{1, 0}}
+	game := newTestGame(t, NewScriptedActions(east, east, MobAction{ActWait, nil}),
+		"#######",
+		"#@....#",
+		"#######",
+	)
+	game.Run()
+
+	if game.state != GameClosed {
+		t.Errorf("Game is %s after the script ran out, want GameClosed", game.state)
+	}
+	if loc := game.Player().Loc(); loc != (Vector{3, 1}) {
+		t.Errorf("Player is at %s, want (3, 1)", loc)
+	}
+	if game.turn != 3 {
+		t.Errorf("Turn is %d, want 3", game.turn)
+	}
+	screen := game.UI().(HeadlessUI).Screen()
+	if !strings.Contains(strings.Join(screen, "\n"), "@") {
+		t.Errorf("Player isn't on the screen:\n%s", strings.Join(screen, "\n"))
+	}
+}
+
+func TestRunTravel(t *testing.T) {
+	game := newTestGame(t, NewScriptedActions(MobAction{ActTravel, Vector{5, 2}}),
+		"#######",
+		"#@....#",
+		"#####.#",
+		"#######",
+	)
+	// The Player only travels across Tiles they know
+	game.currentDungeon.SetFlag(FlagSeen)
+	game.Run()
+
+	if loc := game.Player().Loc(); loc != (Vector{5, 2}) {
+		t.Errorf("Player is at %s, want (5, 2)", loc)
+	}
+	if game.turn != 4 {
+		t.Errorf("Turn is %d, want 4", game.turn)
+	}
+}
+
+func TestNewGameConfig(t *testing.T) {
+	arena := func(log *log.Logger, dice *rand.Rand, width, height, depth int) *Dungeon {
+		d := NewDungeon(width, height, log)
+		d.depth = depth
+		for y := 0; y < height; y++ {
+			for x := 0; x < width; x++ {
+				d.tiles[y][x] = NewTile('.', termbox.ColorWhite, FlagCrossable)
+			}
+		}
+		return d
+	}
+	config := GameConfig{
+		Seed:      1,
+		Width:     9,
+		Height:    9,
+		Generator: LevelGenerator(arena),
+		StartingKit: func(game *Game, player Player) {
+			player.AddToInventory(NewItem("rock", '*', 1))
+		},
+		NewUI: NewHeadlessUI(9, 9, NewScriptedActions()),
+	}
+	game, err := NewGame(log.New(ioutil.Discard, "", 0), config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer game.Close()
+
+	if loc := game.Player().Loc(); loc != (Vector{4, 4}) {
+		t.Errorf("Player started at %s, want the middle of the arena", loc)
+	}
+	if inventory := game.Player().Inventory(); len(inventory) != 1 || inventory[0].Name() != "rock" {
+		t.Errorf("Player started with %v, want a rock", inventory)
+	}
+	if mobs := game.currentDungeon.Mobs(); len(mobs) != 1 {
+		t.Errorf("Arena has %d Mobs, want just the Player", len(mobs))
+	}
+
+	config.Generator = nil
+	if _, err := NewGame(log.New(ioutil.Discard, "", 0), config); err == nil {
+		t.Error("NewGame made a Game with no generator")
+	}
+}
+
+func TestDoMobActionArmor(t *testing.T) {
+	game := newTestGame(t, NewScriptedActions(),
+		"#####",
+		"#@..#",
+		"#####",
+	)
+	player := game.Player()
+	helmet := NewArmor("helmet", '[', 1, "head", 1)
+	player.AddToInventory(helmet)
+
+	if game.doMobAction(player, MobAction{ActEquip, NewItem("rock", '*', 1)}) {
+		t.Error("Wore a rock")
+	}
+	if !game.doMobAction(player, MobAction{ActEquip, helmet}) {
+		t.Fatal("Couldn't wear the helmet")
+	}
+	if player.Defense() != 1 || len(player.Inventory()) != 0 {
+		t.Errorf("Player has defense %d and inventory %v after wearing the helmet", player.Defense(), player.Inventory())
+	}
+
+	if damage := player.AttackedFor(DamagePacket{{DamageBlunt, 3}}); damage != 2 || player.Health() != MobDefaultHealth-2 {
+		t.Errorf("Player took %d damage from a 3 damage hit in a helmet, want 2", damage)
+	}
+
+	if !game.doMobAction(player, MobAction{ActUnequip, uint(0)}) || player.Defense() != 0 {
+		t.Errorf("Couldn't take off the helmet")
+	}
+	if game.doMobAction(player, MobAction{ActUnequip, uint(0)}) {
+		t.Errorf("Took off a helmet that wasn't there")
+	}
+}
+
+func TestDeathDropsEquipment(t *testing.T) {
+	game := newTestGame(t, NewScriptedActions(),
+		"#####",
+		"#@.o#",
+		"#####",
+	)
+	orc := game.currentDungeon.MobAt(Vector{3, 1})
+	helmet := NewArmor("helmet", '[', 1, "head", 1)
+	sword := NewWeapon("sword", ']', 5, []DamageRoll{{DamageSlashing, roll.MustParse("1d8+2")}}, 1)
+	orc.AddToInventory(helmet)
+	orc.AddToInventory(sword)
+	if !orc.Equip(helmet) || !orc.Wield(sword, 0) {
+		t.Fatal("Orc couldn't arm itself")
+	}
+
+	orc.AttackedFor(DamagePacket{{DamageFire, 1000}})
+	if !orc.Dead() {
+		t.Fatal("Orc survived")
+	}
+	items := game.currentDungeon.ItemsAt(Vector{3, 1})
+	if len(items) != 3 || items[1] != sword || items[2] != helmet {
+		t.Errorf("Dead orc left %v, want its corpse, sword and helmet", items)
+	}
+	if orc.Defense() != 0 || orc.Wielding()[0] != nil {
+		t.Error("Dead orc still has its sword and helmet on")
+	}
+}
+
+func TestWorldTickEffects(t *testing.T) {
+	game := newTestGame(t, NewScriptedActions(),
+		"#####",
+		"#@..#",
+		"#####",
+	)
+	player := game.Player()
+	player.AddEffect(Effect{EffectPoison, 2, 2})
+	player.AddEffect(Effect{EffectBlind, 3, 0})
+
+	for i := 0; i < 2; i++ {
+		game.currentDungeon.scheduler.Spend(player, EnergyPerTurn)
+		game.WorldTick()
+	}
+	if player.Health() != MobDefaultHealth-4 {
+		t.Errorf("Player has %d health after two turns of poison 2, want %d", player.Health(), MobDefaultHealth-4)
+	}
+	if player.HasEffect(EffectPoison) || !player.HasEffect(EffectBlind) {
+		t.Errorf("Player has effects %v after two turns, want just blindness", player.Effects())
+	}
+	if !game.currentDungeon.Tile(player.Loc()).Visible() || game.currentDungeon.Tile(Vector{2, 1}).Visible() {
+		t.Error("Blind Player can see more than their own tile")
+	}
+	if !strings.Contains(strings.Join(game.messages, "\n"), "is no longer poisoned") {
+		t.Errorf("No message when the poison wore off: %v", game.messages)
+	}
+}
+
+func TestDoMobActionUse(t *testing.T) {
+	game := newTestGame(t, NewScriptedActions(),
+		"#######",
+		"#@....#",
+		"#######",
+	)
+	player := game.Player()
+	rock := NewItem("rock", '*', 1)
+	player.AddToInventory(rock)
+	if game.doMobAction(player, MobAction{ActUse, rock}) {
+		t.Error("Used a rock")
+	}
+
+	potion := NewConsumable("potion", '!', 1, ConsumablePotion,
+		[]Use{{UseHeal, 5}, {UseLight, 4}},
+		[]Effect{{EffectHaste, 10, 100}},
+	)
+	player.AddToInventory(potion)
+	player.AttackedFor(DamagePacket{{DamagePoison, 3}})
+	if !game.doMobAction(player, MobAction{ActUse, potion}) {
+		t.Fatal("Couldn't quaff the potion")
+	}
+	if player.Health() != MobDefaultHealth {
+		t.Errorf("Player has %d health after healing, want %d", player.Health(), MobDefaultHealth)
+	}
+	if player.LightRadius() != 4 || !player.HasEffect(EffectHaste) {
+		t.Errorf("Player has light radius %d and effects %v, want 4 and haste", player.LightRadius(), player.Effects())
+	}
+	if len(player.Inventory()) != 1 {
+		t.Errorf("Potion wasn't used up: %v", player.Inventory())
+	}
+
+	scroll := NewConsumable("scroll", '?', 1, ConsumableScroll, []Use{{UseTeleport, 0}, {UseMapping, 0}}, nil)
+	player.AddToInventory(scroll)
+	game.doMobAction(player, MobAction{ActUse, scroll})
+	if loc := player.Loc(); game.currentDungeon.MobAt(loc) != player || !game.currentDungeon.Tile(loc).Crossable() {
+		t.Errorf("Player teleported somewhere odd: %s", loc)
+	}
+	if !game.currentDungeon.Tile(Vector{0, 0}).Seen() || !game.currentDungeon.Tile(Vector{5, 1}).Seen() {
+		t.Error("Magic mapping didn't show the whole level")
+	}
+}
+
+func TestDoMobActionFireAndThrow(t *testing.T) {
+	game := newTestGame(t, NewScriptedActions(),
+		"########",
+		"#@...o.#",
+		"########",
+	)
+	player := game.Player()
+	orc := game.currentDungeon.MobAt(Vector{5, 1})
+	orc.SetMaxHealth(1000)
+
+	if game.doMobAction(player, MobAction{ActFire, Vector{5, 1}}) {
+		t.Error("Fired without a ranged weapon")
+	}
+	bow := NewWeapon("bow", '}', 2, []DamageRoll{{DamagePiercing, roll.MustParse("1d6")}}, 0)
+	bow.SetRange(8)
+	player.AddToInventory(bow)
+	player.Wield(bow, 0)
+	for i := 0; i < 20; i++ {
+		if !game.doMobAction(player, MobAction{ActFire, Vector{6, 1}}) {
+			t.Fatal("Couldn't fire the bow")
+		}
+	}
+	if attacks := attacksBy("Player", game.messages); attacks != 20 {
+		t.Errorf("Player fired at the orc %d times, want 20: %v", attacks, game.messages)
+	}
+
+	rock := NewItem("rock", '*', 1)
+	player.AddToInventory(rock)
+	if !game.doMobAction(player, MobAction{ActThrow, ThrowTarget{rock, Vector{6, 1}}}) {
+		t.Fatal("Couldn't throw the rock")
+	}
+	if len(player.Inventory()) != 0 || rock.Loc() != (Vector{5, 1}) {
+		t.Errorf("Thrown rock is at %s, want under the orc at (5, 1)", rock.Loc())
+	}
+	if items := game.currentDungeon.ItemsAt(Vector{5, 1}); len(items) != 1 || items[0] != rock {
+		t.Errorf("Items under the orc: %v, want the rock", items)
+	}
+}
+
+func TestDoors(t *testing.T) {
+	game := newTestGame(t, NewScriptedActions(),
+		"#######",
+		"#@..#.#",
+		"#######",
+	)
+	d := game.currentDungeon
+	player := game.Player()
+	player.SetLightRadius(5)
+	door := NewDoor(DoorLocked, "vault")
+	door.SetLoc(Vector{2, 1})
+	d.AddFeature(door)
+	game.updatePlayerFOV()
+	if d.Tile(Vector{3, 1}).Visible() {
+		t.Error("Player can see through a closed door")
+	}
+
+	if game.MoveOrAct(player, MoveEast) || door.State() != DoorLocked {
+		t.Fatal("Player opened a locked door without the key")
+	}
+	player.AddToInventory(NewKey("wrong key", '-', 1, "cellar"))
+	if game.doMobAction(player, MobAction{ActOpen, MoveEast}) {
+		t.Fatal("Player opened a locked door with the wrong key")
+	}
+	player.AddToInventory(NewKey("vault key", '-', 1, "vault"))
+	if !game.MoveOrAct(player, MoveEast) || door.State() != DoorOpen || player.Loc() != (Vector{1, 1}) {
+		t.Fatalf("Bumping the locked door with the key didn't just open it: door %s, Player at %s", door.State(), player.Loc())
+	}
+	game.updatePlayerFOV()
+	if !d.Tile(Vector{3, 1}).Visible() {
+		t.Error("Player can't see through an open door")
+	}
+
+	if !game.MoveOrAct(player, MoveEast) || player.Loc() != door.Loc() {
+		t.Fatal("Player couldn't walk into the open doorway")
+	}
+	if game.doMobAction(player, MobAction{ActClose, Vector{0, 0}}) {
+		t.Error("Player closed the door on themselves")
+	}
+	game.MoveOrAct(player, MoveEast)
+	if !game.doMobAction(player, MobAction{ActClose, MoveWest}) || door.State() != DoorClosed {
+		t.Error("Couldn't close the door")
+	}
+	if game.doMobAction(player, MobAction{ActOpen, MoveEast}) {
+		t.Error("Opened a wall")
+	}
+}
+
+func TestHuntOpensDoors(t *testing.T) {
+	game := newTestGame(t, NewScriptedActions(),
+		"#######",
+		"#@.o..#",
+		"#######",
+	)
+	d := game.currentDungeon
+	orc := d.MobAt(Vector{3, 1})
+	door := NewDoor(DoorOpen, "")
+	door.SetLoc(Vector{2, 1})
+	d.AddFeature(door)
+	// The orc sees the Player through the open door, which then shuts
+	orc.Tick(0, game.dice)
+	door.SetState(DoorClosed)
+
+	action := orc.Tick(0, game.dice)
+	if action.action != ActOpen || action.target != MoveWest {
+		t.Fatalf("Orc chasing the Player through a closed door wants to %s", action)
+	}
+	if !game.doMobAction(orc, action) || door.State() != DoorOpen {
+		t.Error("Orc couldn't open the door")
+	}
+}
+
+func TestHuntInTheDark(t *testing.T) {
+	game := newTestGame(t, NewScriptedActions(),
+		"#######",
+		"#@...o#",
+		"#######",
+	)
+	d := game.currentDungeon
+	orc := d.MobAt(Vector{5, 1}).(*mob)
+	game.player.SetLightRadius(0)
+	game.updatePlayerFOV()
+	orc.Tick(0, game.dice)
+	if orc.chasing {
+		t.Error("Orc noticed the Player in the dark from 4 tiles away")
+	}
+
+	game.player.SetLightRadius(2)
+	game.updatePlayerFOV()
+	if action := orc.Tick(0, game.dice); !orc.chasing || action.action != ActMove || action.target != MoveWest {
+		t.Errorf("Orc didn't chase the lit up Player, wants to %s", action)
+	}
+}
+
+func TestHuntWaitsBehindMobs(t *testing.T) {
+	game := newTestGame(t, NewScriptedActions(),
+		"######",
+		"#@.oo#",
+		"######",
+	)
+	orc := game.currentDungeon.MobAt(Vector{4, 1}).(*mob)
+	if action := orc.stepTowards(game.player.Loc()); action.action != ActWait {
+		t.Errorf("Orc behind another orc wants to %s", action)
+	}
+	front := game.currentDungeon.MobAt(Vector{3, 1}).(*mob)
+	if action := front.stepTowards(Vector{4, 1}); action.action != ActMove || action.target != MoveEast {
+		t.Errorf("Orc heading for the Mob next to it wants to %s", action)
+	}
+}
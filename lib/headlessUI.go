@@ -0,0 +1,157 @@
+package gorl
+
+import (
+	"fmt"
+	"strings"
+)
+
+// An ActionSource supplies the Player's actions to a headless UI, in place of
+// a person at a keyboard.
+type ActionSource interface {
+	NextAction(*Game) (MobAction, GameState)
+}
+
+// ScriptedActions is an ActionSource that takes each of its actions in turn,
+// then closes the Game.
+type ScriptedActions struct {
+	actions []MobAction
+}
+
+// NewScriptedActions returns an ActionSource that plays actions in order.
+func NewScriptedActions(actions ...MobAction) *ScriptedActions {
+	return &ScriptedActions{actions}
+}
+
+// NextAction implements ActionSource
+func (s *ScriptedActions) NextAction(game *Game) (MobAction, GameState) {
+	if len(s.actions) == 0 {
+		return MobAction{ActNone, nil}, GameClosed
+	}
+	action := s.actions[0]
+	s.actions = s.actions[1:]
+	if action.action == ActNone {
+		return action, GamePlayerTurn
+	}
+	return action, GameWorldTurn
+}
+
+// A HeadlessUI is a UI that needs no terminal. It takes the Player's actions
+// from an ActionSource, and paints what the Player can see to an in-memory
+// grid instead of the screen.
+type HeadlessUI interface {
+	UI
+	Screen() []string
+	Messages() []string
+}
+
+type headlessUI struct {
+	game          *Game
+	actions       ActionSource
+	width, height int
+	screen        [][]rune
+	dungeon       *Dungeon
+	center        Vector
+	messages      []string
+	state         State
+	dirty         bool
+}
+
+// NewHeadlessUI returns a UIFactory for HeadlessUIs that are width by height
+// cells, and take their input from actions.
+func NewHeadlessUI(width, height int, actions ActionSource) UIFactory {
+	return func(game *Game) (UI, error) {
+		if width <= 0 || height <= 0 {
+			return nil, fmt.Errorf("Bad headless UI size: %dx%d", width, height)
+		}
+		screen := make([][]rune, height)
+		for y := range screen {
+			screen[y] = []rune(strings.Repeat(" ", width))
+		}
+		ui := &headlessUI{
+			game:    game,
+			actions: actions,
+			width:   width,
+			height:  height,
+			screen:  screen,
+			state:   StateGame,
+			dirty:   true,
+		}
+		return ui, nil
+	}
+}
+
+// UI interface implementation
+
+func (ui *headlessUI) Close() {
+	ui.state = StateClosed
+}
+
+func (ui *headlessUI) Paintables() []Paintable {
+	return nil
+}
+
+func (ui *headlessUI) State() State {
+	return ui.state
+}
+
+func (ui *headlessUI) MarkDirty() {
+	ui.dirty = true
+}
+
+func (ui *headlessUI) IsDirty() bool {
+	return ui.dirty
+}
+
+// Paint redraws the grid, centered on the camera, if the UI is dirty.
+func (ui *headlessUI) Paint() {
+	if !ui.dirty || ui.dungeon == nil {
+		return
+	}
+	ne := ui.center.Add(Vector{-ui.width / 2, -ui.height / 2})
+	for y := 0; y < ui.height; y++ {
+		for x := 0; x < ui.width; x++ {
+			char, _, seen := cameraCell(ui.dungeon, ne.Add(Vector{x, y}))
+			if !seen {
+				char = ' '
+			}
+			ui.screen[y][x] = char
+		}
+	}
+	ui.dirty = false
+}
+
+func (ui *headlessUI) DoEvent() (MobAction, GameState) {
+	if ui.state == StateClosed {
+		ui.game.log.Panic("Can't handle event while closed")
+	}
+	return ui.actions.NextAction(ui.game)
+}
+
+func (ui *headlessUI) PointCameraAt(d *Dungeon, c Vector) {
+	ui.dungeon = d
+	ui.center = c
+}
+
+func (ui *headlessUI) MessagesWanted() int {
+	return ui.height
+}
+
+func (ui *headlessUI) SetMessages(messages []string) {
+	ui.messages = messages
+}
+
+// HeadlessUI implementation
+
+// Screen returns the grid as of the last Paint, one string per row.
+func (ui *headlessUI) Screen() []string {
+	rows := make([]string, ui.height)
+	for y, row := range ui.screen {
+		rows[y] = string(row)
+	}
+	return rows
+}
+
+// Messages returns the most recent messages
+func (ui *headlessUI) Messages() []string {
+	return ui.messages
+}
@@ -6,6 +6,19 @@ type Item interface {
 	Weight() int
 }
 
+func init() {
+	RegisterFeatureType("item", &item{}, itemRecord{},
+		func(f Feature, s *Saver) (interface{}, error) {
+			return f.(*item).record(), nil
+		},
+		func(record interface{}, l *Loader) (Feature, error) {
+			i := &item{}
+			i.restore(record.(itemRecord))
+			return i, nil
+		},
+	)
+}
+
 type item struct {
 	feature
 	weight int
@@ -23,3 +36,18 @@ func NewItem(name string, char rune, weight int) Item {
 func (i *item) Weight() int {
 	return i.weight
 }
+
+// itemRecord is the saved form of an item
+type itemRecord struct {
+	Feature featureRecord
+	Weight  int
+}
+
+func (i *item) record() itemRecord {
+	return itemRecord{i.feature.record(), i.weight}
+}
+
+func (i *item) restore(r itemRecord) {
+	i.feature.restore(r.Feature)
+	i.weight = r.Weight
+}
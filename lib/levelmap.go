@@ -0,0 +1,633 @@
+package gorl
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/nsf/termbox-go"
+)
+
+// A LevelMap is a level written down, for filing with generator bugs or
+// designing levels by hand: its Tiles, and everything on them but the Player.
+// Dungeon.LevelMap makes one, to be written out as JSON, which keeps
+// everything, or as ASCII, which only keeps what's on top of each Tile. A
+// LevelMap is a Generator, so a Game can be played on it.
+//
+// Monsters and items from Content are remade from it when the level is, so
+// anything that's happened to them since is forgotten.
+type LevelMap struct {
+	Depth int      `json:"depth"`
+	Start MapPoint `json:"start"`
+	// Tiles are the rows of the level, each character one of TileKinds
+	Tiles     []string           `json:"tiles"`
+	TileKinds map[string]MapTile `json:"tile_kinds"`
+	Things    []MapThing         `json:"things"`
+
+	content *Content
+}
+
+// A MapPoint is a location on a LevelMap
+type MapPoint struct {
+	X int `json:"x"`
+	Y int `json:"y"`
+}
+
+// A MapTile is a kind of Tile on a LevelMap
+type MapTile struct {
+	Color       string `json:"color"`
+	Crossable   bool   `json:"crossable"`
+	BlocksLight bool   `json:"blocks_light"`
+}
+
+// The kinds of MapThing
+const (
+	MapMonster = "monster"
+	MapItem    = "item"
+	MapKey     = "key"
+	MapDoor    = "door"
+	MapStairs  = "stairs"
+	MapFeature = "feature"
+)
+
+// A MapThing is a Mob, Item or Feature on a LevelMap. Monsters and items with
+// an ID are made from Content; the rest are made from their name, glyph and
+// color, and whatever else their kind needs.
+type MapThing struct {
+	Kind  string `json:"kind"`
+	X     int    `json:"x"`
+	Y     int    `json:"y"`
+	ID    string `json:"id,omitempty"`
+	Name  string `json:"name"`
+	Glyph string `json:"glyph"`
+	Color string `json:"color"`
+	// Weight is for items and keys
+	Weight int `json:"weight,omitempty"`
+	// State is for doors: "open", "closed" or "locked"
+	State string `json:"state,omitempty"`
+	// Lock is for doors and keys
+	Lock string `json:"lock,omitempty"`
+	// Down is for stairs
+	Down bool `json:"down,omitempty"`
+	// The rest are for features
+	Crossable   bool   `json:"crossable,omitempty"`
+	BlocksLight bool   `json:"blocks_light,omitempty"`
+	LightRadius int    `json:"light_radius,omitempty"`
+	LightColor  string `json:"light_color,omitempty"`
+}
+
+// LevelMap writes down the Dungeon, naming monsters and items by their IDs in
+// content where it can.
+func (d *Dungeon) LevelMap(content *Content) *LevelMap {
+	m := &LevelMap{
+		Depth:     d.depth,
+		Start:     MapPoint{d.origin.x, d.origin.y},
+		TileKinds: make(map[string]MapTile),
+		content:   content,
+	}
+	for y := 0; y < d.height; y++ {
+		row := make([]rune, d.width)
+		for x := 0; x < d.width; x++ {
+			tile := d.tiles[y][x]
+			row[x] = tile.c
+			if _, ok := m.TileKinds[string(tile.c)]; !ok {
+				m.TileKinds[string(tile.c)] = MapTile{
+					formatColor(tile.color),
+					tile.Crossable(),
+					tile.flags&FlagBlocksLight != 0,
+				}
+			}
+		}
+		m.Tiles = append(m.Tiles, string(row))
+	}
+	for _, loc := range d.featureLocs() {
+		fg := d.features[loc]
+		if fg.feature != nil {
+			m.Things = append(m.Things, newMapThing(fg.feature, content))
+		}
+		for _, item := range fg.items {
+			m.Things = append(m.Things, newMapThing(item, content))
+		}
+		if _, ok := fg.mob.(*player); fg.mob != nil && !ok {
+			m.Things = append(m.Things, newMapThing(fg.mob, content))
+		}
+	}
+	return m
+}
+
+// newMapThing writes down f
+func newMapThing(f Feature, content *Content) MapThing {
+	thing := MapThing{
+		X:     f.Loc().x,
+		Y:     f.Loc().y,
+		Name:  f.Name(),
+		Glyph: string(f.Char()),
+		Color: formatColor(f.Color()),
+	}
+	switch f := f.(type) {
+	case Mob:
+		thing.Kind, thing.ID = MapMonster, content.monsterID(f.Name())
+	case Key:
+		thing.Kind, thing.Weight, thing.Lock = MapKey, f.Weight(), f.LockID()
+	case Item:
+		thing.Kind, thing.ID = MapItem, content.itemID(f.Name())
+		if thing.ID == "" {
+			thing.Weight = f.Weight()
+		}
+	case Door:
+		thing.Kind, thing.State, thing.Lock = MapDoor, f.State().String(), f.LockID()
+	case Stairs:
+		thing.Kind, thing.Down = MapStairs, f.Down()
+	default:
+		thing.Kind = MapFeature
+		thing.Crossable = f.Flags()&FlagCrossable != 0
+		thing.BlocksLight = f.Flags()&FlagBlocksLight != 0
+		if f.LightRadius() > 0 {
+			thing.LightRadius, thing.LightColor = f.LightRadius(), formatLight(f.LightColor())
+		}
+	}
+	return thing
+}
+
+// monsterID returns the ID of the monster template that makes Mobs called
+// name, or "" if there isn't one.
+func (c *Content) monsterID(name string) string {
+	if c == nil {
+		return ""
+	}
+	id := ""
+	for templateID, t := range c.Monsters {
+		if t.Name == name && (id == "" || templateID < id) {
+			id = templateID
+		}
+	}
+	return id
+}
+
+// itemID returns the ID of the item template that makes Items called name, or
+// "" if there isn't one.
+func (c *Content) itemID(name string) string {
+	if c == nil {
+		return ""
+	}
+	id := ""
+	for templateID, t := range c.Items {
+		if t.Name == name && (id == "" || templateID < id) {
+			id = templateID
+		}
+	}
+	return id
+}
+
+// Generate makes the LevelMap's level at depth. The level is the map's own
+// size, whatever width and height are.
+func (m *LevelMap) Generate(log *log.Logger, dice *rand.Rand, width, height, depth int) *Dungeon {
+	d, err := m.dungeon(log, depth)
+	if err != nil {
+		log.Panic(err)
+	}
+	return d
+}
+
+// dungeon makes the LevelMap's level at depth, or returns an error saying
+// what's wrong with the map.
+func (m *LevelMap) dungeon(log *log.Logger, depth int) (*Dungeon, error) {
+	if len(m.Tiles) == 0 {
+		return nil, fmt.Errorf("no tiles")
+	}
+	kinds := make(map[rune]Tile)
+	for glyph, kind := range m.TileKinds {
+		c, err := parseGlyph(glyph)
+		if err != nil {
+			return nil, fmt.Errorf("tile_kinds: %s", err)
+		}
+		color, err := parseColor(kind.Color)
+		if err != nil {
+			return nil, fmt.Errorf("tile_kinds %q: %s", glyph, err)
+		}
+		flags := Flag(0)
+		if kind.Crossable {
+			flags |= FlagCrossable
+		}
+		if kind.BlocksLight {
+			flags |= FlagBlocksLight
+		}
+		kinds[c] = NewTile(c, color, flags)
+	}
+
+	width := utf8.RuneCountInString(m.Tiles[0])
+	d := NewDungeon(width, len(m.Tiles), log)
+	d.depth = depth
+	for y, row := range m.Tiles {
+		if n := utf8.RuneCountInString(row); n != width {
+			return nil, fmt.Errorf("tiles %d: %d wide, want %d", y, n, width)
+		}
+		for x, c := range []rune(row) {
+			tile, ok := kinds[c]
+			if !ok {
+				return nil, fmt.Errorf("tiles %d: %q isn't in tile_kinds", y, c)
+			}
+			d.tiles[y][x] = tile
+		}
+	}
+	onMap := func(p MapPoint) bool {
+		return p.X >= 0 && p.Y >= 0 && p.X < d.width && p.Y < d.height
+	}
+	if !onMap(m.Start) {
+		return nil, fmt.Errorf("start %d,%d is off the map", m.Start.X, m.Start.Y)
+	}
+	d.origin = Vector{m.Start.X, m.Start.Y}
+	// Without stairs up, anyone coming down arrives at the start
+	d.upStairs, d.downStairs = d.origin, d.origin
+
+	for i, thing := range m.Things {
+		if !onMap(MapPoint{thing.X, thing.Y}) {
+			return nil, fmt.Errorf("things %d (%q): %d,%d is off the map", i, thing.Name, thing.X, thing.Y)
+		}
+		f, err := thing.feature(m.content, d)
+		if err != nil {
+			return nil, fmt.Errorf("things %d (%q): %s", i, thing.Name, err)
+		}
+		loc := Vector{thing.X, thing.Y}
+		f.SetLoc(loc)
+		switch f := f.(type) {
+		case Mob:
+			if d.MobAt(loc) != nil {
+				return nil, fmt.Errorf("things %d (%q): there's already a monster at %s", i, thing.Name, loc)
+			}
+			d.AddMob(f)
+		case Item:
+			d.AddItem(f)
+		default:
+			if d.FeatureAt(loc) != nil {
+				return nil, fmt.Errorf("things %d (%q): there's already a feature at %s", i, thing.Name, loc)
+			}
+			d.AddFeature(f)
+			if stairs, ok := f.(Stairs); ok && stairs.Down() {
+				d.downStairs = loc
+			} else if ok {
+				d.upStairs = loc
+			}
+		}
+	}
+	return d, nil
+}
+
+// feature makes the thing, on d
+func (thing MapThing) feature(content *Content, d *Dungeon) (Feature, error) {
+	char, err := parseGlyph(thing.Glyph)
+	if err != nil {
+		return nil, err
+	}
+	color, err := parseColor(thing.Color)
+	if err != nil {
+		return nil, err
+	}
+	switch thing.Kind {
+	case MapMonster:
+		if thing.ID != "" {
+			return content.NewMonster(thing.ID, d.log, d)
+		}
+		mob := NewMob(thing.Name, char, d.log, d)
+		mob.SetColor(color)
+		return mob, nil
+	case MapItem:
+		if thing.ID != "" {
+			return content.NewItem(thing.ID)
+		}
+		item := NewItem(thing.Name, char, thing.Weight)
+		item.SetColor(color)
+		return item, nil
+	case MapKey:
+		key := NewKey(thing.Name, char, thing.Weight, thing.Lock)
+		key.SetColor(color)
+		return key, nil
+	case MapDoor:
+		for state := DoorOpen; state <= DoorLocked; state++ {
+			if state.String() == thing.State {
+				return NewDoor(state, thing.Lock), nil
+			}
+		}
+		return nil, fmt.Errorf("unknown door state %q", thing.State)
+	case MapStairs:
+		return NewStairs(thing.Down), nil
+	case MapFeature:
+		f := NewFeature(thing.Name, char).(*feature)
+		f.color = color
+		if thing.Crossable {
+			f.flags |= FlagCrossable
+		}
+		f.SetBlocksLight(thing.BlocksLight)
+		f.lightRadius = thing.LightRadius
+		if f.lightColor, err = parseLight(thing.LightColor); err != nil {
+			return nil, err
+		}
+		return f, nil
+	}
+	return nil, fmt.Errorf("unknown kind %q", thing.Kind)
+}
+
+// check returns an error if the LevelMap can't be made into a level
+func (m *LevelMap) check() error {
+	_, err := m.dungeon(log.New(ioutil.Discard, "", 0), m.Depth)
+	return err
+}
+
+// WriteJSON writes the LevelMap as JSON
+func (m *LevelMap) WriteJSON(w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "    ")
+	return encoder.Encode(m)
+}
+
+// ParseLevelJSON reads a LevelMap written by WriteJSON from r, making its
+// monsters and items from content.
+func ParseLevelJSON(r io.Reader, content *Content) (*LevelMap, error) {
+	m := &LevelMap{content: content}
+	if err := decodeContent(r, m); err != nil {
+		return nil, err
+	}
+	if err := m.check(); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// defaultMapLegend is what the characters in an ASCII level map stand for
+// unless its legend says otherwise: as in a vault, plus locked doors, doors
+// left open, stairs, keys and the start. Spaces are solid rock.
+var defaultMapLegend = map[rune]vaultCell{
+	' ':  {vaultNothing, ""},
+	'#':  {vaultWall, ""},
+	'.':  {vaultFloor, ""},
+	'+':  {vaultDoor, ""},
+	'=':  {vaultLockedDoor, ""},
+	'\'': {vaultOpenDoor, ""},
+	'<':  {vaultUpStairs, ""},
+	'>':  {vaultDownStairs, ""},
+	'-':  {vaultKey, ""},
+	'@':  {vaultStart, ""},
+}
+
+// mapLockID is the lock of every locked door and key in an ASCII level map,
+// as there's no drawing which key opens which door
+const mapLockID = "level map"
+
+// WriteASCII writes the LevelMap as a picture of the level, in the same format
+// as a vault file. Each Tile shows whatever's on top of it, as the Player
+// would see it: a monster, then a Feature, then the last Item dropped there.
+// Anything that isn't on top is left out, as is anything that isn't in
+// Content, and where different things look alike only the first is kept.
+// Locked doors are drawn as '=', as otherwise they'd look like any other, and
+// every key opens every one of them.
+func (m *LevelMap) WriteASCII(w io.Writer) error {
+	legend := make(map[rune]string)
+	for c, cell := range defaultMapLegend {
+		legend[c] = cell.String()
+	}
+	var notes []string
+	noted := make(map[string]bool)
+	describe := func(c rune, description string) {
+		if existing, ok := legend[c]; !ok {
+			legend[c] = description
+		} else if note := fmt.Sprintf("; %c is also %s", c, description); existing != description && !noted[note] {
+			notes = append(notes, note)
+			noted[note] = true
+		}
+	}
+
+	grid := make([][]rune, len(m.Tiles))
+	for y, row := range m.Tiles {
+		grid[y] = []rune(row)
+		for _, c := range grid[y] {
+			if m.TileKinds[string(c)].Crossable {
+				describe(c, "floor")
+			} else {
+				describe(c, "wall")
+			}
+		}
+	}
+	// Monsters are on top of Features, which are on top of Items
+	layers := map[string]int{MapItem: 0, MapKey: 0, MapDoor: 1, MapStairs: 1, MapFeature: 1, MapMonster: 2}
+	tops := make(map[MapPoint]MapThing)
+	for _, thing := range m.Things {
+		at := MapPoint{thing.X, thing.Y}
+		if top, ok := tops[at]; !ok || layers[thing.Kind] >= layers[top.Kind] {
+			tops[at] = thing
+		}
+	}
+	var locs []MapPoint
+	for at := range tops {
+		locs = append(locs, at)
+	}
+	sort.Slice(locs, func(i, j int) bool {
+		return locs[i].Y < locs[j].Y || (locs[i].Y == locs[j].Y && locs[i].X < locs[j].X)
+	})
+	for _, at := range locs {
+		thing := tops[at]
+		c, _ := utf8.DecodeRuneInString(thing.Glyph)
+		description := thing.legend()
+		if description == "locked door" {
+			c = '='
+		}
+		if description == "" {
+			notes = append(notes, fmt.Sprintf("; %s at %d,%d isn't in the content", thing.Name, at.X, at.Y))
+			continue
+		}
+		if at.Y < len(grid) && at.X < len(grid[at.Y]) {
+			grid[at.Y][at.X] = c
+			describe(c, description)
+		}
+	}
+	// The start is shown, unless it's at the stairs up, which stand for it
+	top, covered := tops[m.Start]
+	onGrid := m.Start.Y >= 0 && m.Start.Y < len(grid) && m.Start.X >= 0 && m.Start.X < len(grid[m.Start.Y])
+	if onGrid && !(covered && top.legend() == "stairs up") {
+		if covered {
+			notes = append(notes, fmt.Sprintf("; %s at %d,%d is under the start", top.Name, m.Start.X, m.Start.Y))
+		}
+		grid[m.Start.Y][m.Start.X] = '@'
+	}
+
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "; GoRL level map, depth %d\n", m.Depth)
+	sort.Strings(notes)
+	for _, note := range notes {
+		fmt.Fprintln(&b, note)
+	}
+	var chars []rune
+	for c := range legend {
+		if cell, ok := defaultMapLegend[c]; !ok || cell.String() != legend[c] {
+			chars = append(chars, c)
+		}
+	}
+	sort.Slice(chars, func(i, j int) bool { return chars[i] < chars[j] })
+	for _, c := range chars {
+		fmt.Fprintf(&b, "%c: %s\n", c, legend[c])
+	}
+	fmt.Fprintln(&b)
+	for _, row := range grid {
+		fmt.Fprintln(&b, string(row))
+	}
+	_, err := w.Write(b.Bytes())
+	return err
+}
+
+// legend returns how the thing is written in an ASCII map's legend, or "" if
+// it can't be.
+func (thing MapThing) legend() string {
+	switch thing.Kind {
+	case MapMonster, MapItem:
+		if thing.ID == "" {
+			return ""
+		}
+		return thing.Kind + " " + thing.ID
+	case MapDoor:
+		switch thing.State {
+		case DoorOpen.String():
+			return "open door"
+		case DoorLocked.String():
+			return "locked door"
+		}
+		return "door"
+	case MapKey:
+		return "key"
+	case MapStairs:
+		if thing.Down {
+			return "stairs down"
+		}
+		return "stairs up"
+	case MapFeature:
+		return "feature " + thing.Name
+	}
+	return ""
+}
+
+// ParseLevelASCII reads a LevelMap drawn like a vault file from r, as written
+// by WriteASCII, making its monsters and items from content. The legend can
+// also use "open door", "stairs up", "stairs down", "key" and "start", which
+// an apostrophe, '<', '>', '-' and '@' stand for by default, '=' is a locked
+// door, and spaces are solid rock. Every key opens every locked door. Without
+// a start, the Player starts on the stairs up.
+func ParseLevelASCII(r io.Reader, content *Content) (*LevelMap, error) {
+	v, err := parseGrid("map", r, content, defaultMapLegend)
+	if err != nil {
+		return nil, err
+	}
+	m := &LevelMap{
+		Start: MapPoint{-1, -1},
+		TileKinds: map[string]MapTile{
+			"#": {formatColor(newWall().color), false, true},
+			".": {formatColor(newFloor().color), true, false},
+		},
+		content: content,
+	}
+	upStairs := MapPoint{-1, -1}
+	for y, row := range v.grid {
+		tiles := make([]rune, len(row))
+		for x, c := range row {
+			cell := v.legend[c]
+			tiles[x] = '.'
+			thing := MapThing{X: x, Y: y, Glyph: string(c), Color: "default", ID: cell.id, Name: cell.id}
+			switch cell.kind {
+			case vaultNothing, vaultWall:
+				tiles[x] = '#'
+				continue
+			case vaultFloor:
+				continue
+			case vaultStart:
+				m.Start = MapPoint{x, y}
+				continue
+			case vaultDoor, vaultOpenDoor, vaultLockedDoor:
+				thing.Kind, thing.State = MapDoor, DoorClosed.String()
+				if cell.kind == vaultOpenDoor {
+					thing.State = DoorOpen.String()
+				} else if cell.kind == vaultLockedDoor {
+					thing.State, thing.Lock = DoorLocked.String(), mapLockID
+				}
+			case vaultUpStairs, vaultDownStairs:
+				thing.Kind, thing.Down = MapStairs, cell.kind == vaultDownStairs
+				if !thing.Down {
+					upStairs = MapPoint{x, y}
+				}
+			case vaultKey:
+				thing.Kind, thing.Name, thing.Weight, thing.Lock = MapKey, "key", 1, mapLockID
+				thing.Color = formatColor(termbox.ColorYellow | termbox.AttrBold)
+			case vaultItem:
+				thing.Kind = MapItem
+			case vaultMonster:
+				thing.Kind = MapMonster
+			case vaultFeature:
+				thing.Kind, thing.ID = MapFeature, ""
+			}
+			m.Things = append(m.Things, thing)
+		}
+		m.Tiles = append(m.Tiles, string(tiles))
+	}
+	if m.Start.X < 0 {
+		m.Start = upStairs
+	}
+	if m.Start.X < 0 {
+		return nil, fmt.Errorf("no start or stairs up")
+	}
+	if err := m.check(); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// LoadLevelMapFile reads the LevelMap in the file at path: JSON if its name
+// ends in .json, and ASCII otherwise.
+func LoadLevelMapFile(path string, content *Content) (*LevelMap, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return ParseLevelMap(path, f, content)
+}
+
+// ParseLevelMap reads the LevelMap in r, which was read from the file at path:
+// JSON if its name ends in .json, and ASCII otherwise.
+func ParseLevelMap(path string, r io.Reader, content *Content) (*LevelMap, error) {
+	var (
+		m   *LevelMap
+		err error
+	)
+	if strings.EqualFold(filepath.Ext(path), ".json") {
+		m, err = ParseLevelJSON(r, content)
+	} else {
+		m, err = ParseLevelASCII(r, content)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %s", path, err)
+	}
+	return m, nil
+}
+
+// WriteFile writes the LevelMap to the file at path: as JSON if its name ends
+// in .json, and ASCII otherwise.
+func (m *LevelMap) WriteFile(path string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if strings.EqualFold(filepath.Ext(path), ".json") {
+		err = m.WriteJSON(f)
+	} else {
+		err = m.WriteASCII(f)
+	}
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
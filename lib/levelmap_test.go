@@ -0,0 +1,147 @@
+package gorl
+
+import (
+	"bytes"
+	"io/ioutil"
+	"log"
+	"strings"
+	"testing"
+)
+
+const testLevelMap = `; a hand made level
+o: monster orc
+!: item potion of healing
+*: feature statue
+
+#########
+#@..#..>#
+#.!.=.o.#
+#.-.#*..#
+#########
+`
+
+func TestParseLevelASCII(t *testing.T) {
+	m, err := ParseLevelASCII(strings.NewReader(testLevelMap), testContent(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	d := m.Generate(log.New(ioutil.Discard, "", 0), nil, 0, 0, 3)
+	if d.width != 9 || d.height != 5 || d.Depth() != 3 {
+		t.Errorf("Level is %dx%d at depth %d, want 9x5 at depth 3", d.width, d.height, d.Depth())
+	}
+	if d.origin != (Vector{1, 1}) || d.downStairs != (Vector{7, 1}) {
+		t.Errorf("Level starts at %s with stairs down at %s, want (1, 1) and (7, 1)", d.origin, d.downStairs)
+	}
+	if mob := d.MobAt(Vector{6, 2}); mob == nil || mob.Name() != "orc" {
+		t.Errorf("Mob at (6, 2) is %v, want an orc", mob)
+	}
+	if items := d.ItemsAt(Vector{2, 2}); len(items) != 1 || items[0].Name() != "potion of healing" {
+		t.Errorf("Items at (2, 2) are %v, want a potion of healing", items)
+	}
+	if door, ok := d.FeatureAt(Vector{4, 2}).(Door); !ok || door.State() != DoorLocked {
+		t.Errorf("Feature at (4, 2) is %v, want a locked door", d.FeatureAt(Vector{4, 2}))
+	}
+	if !walkable(d)[d.downStairs] {
+		t.Error("The key doesn't open the way to the stairs down")
+	}
+	if statue := d.FeatureAt(Vector{5, 3}); statue == nil || statue.Name() != "statue" || d.FeatureGroup(Vector{5, 3}).Crossable() {
+		t.Errorf("Feature at (5, 3) is %v, want a statue in the way", statue)
+	}
+
+	// Written out and read back in, it's the same map
+	var ascii bytes.Buffer
+	if err := d.LevelMap(testContent(t)).WriteASCII(&ascii); err != nil {
+		t.Fatal(err)
+	}
+	again, err := ParseLevelASCII(strings.NewReader(ascii.String()), testContent(t))
+	if err != nil {
+		t.Fatalf("%s\n%s", err, ascii.String())
+	}
+	if got, want := again.Tiles, m.Tiles; strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("Tiles read back are\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+	if !strings.Contains(ascii.String(), "#.!.=.o.#") {
+		t.Errorf("Locked door isn't written as '='\n%s", ascii.String())
+	}
+	if len(again.Things) != len(m.Things) || again.Start != m.Start {
+		t.Errorf("Read back %d things starting at %v, want %d starting at %v\n%s", len(again.Things), again.Start, len(m.Things), m.Start, ascii.String())
+	}
+}
+
+func TestLevelMapJSONRoundTrip(t *testing.T) {
+	logger := log.New(ioutil.Discard, "", 0)
+	content := testContent(t)
+	config := DefaultGameConfig(1234, content)
+	config.Width, config.Height = 60, 40
+	d := newGame(logger, config).dungeonAt(1)
+
+	var before, after bytes.Buffer
+	if err := d.LevelMap(content).WriteJSON(&before); err != nil {
+		t.Fatal(err)
+	}
+	m, err := ParseLevelJSON(bytes.NewReader(before.Bytes()), content)
+	if err != nil {
+		t.Fatal(err)
+	}
+	loaded := m.Generate(logger, nil, 0, 0, 1)
+	if err := loaded.LevelMap(content).WriteJSON(&after); err != nil {
+		t.Fatal(err)
+	}
+	if before.String() != after.String() {
+		t.Errorf("Level changed on its way through JSON:\n%s\n\n%s", before.String(), after.String())
+	}
+	if loaded.upStairs != d.upStairs || loaded.downStairs != d.downStairs {
+		t.Errorf("Loaded stairs at %s and %s, want %s and %s", loaded.upStairs, loaded.downStairs, d.upStairs, d.downStairs)
+	}
+}
+
+func TestParseLevelErrors(t *testing.T) {
+	tests := []struct {
+		ascii bool
+		level string
+		want  string
+	}{
+		{true, "###\n#.#\n###\n", "no start or stairs up"},
+		{true, "x: monster dragon\n\n#@#\n", `line 1: unknown monster "dragon"`},
+		{true, "#@?#\n", `'?' isn't in the legend`},
+		{false, `{"tiles": ["#.#"], "tile_kinds": {"#": {}}}`, `tiles 0: '.' isn't in tile_kinds`},
+		{false, `{"tiles": ["#", "##"], "tile_kinds": {"#": {}}}`, "tiles 1: 2 wide, want 1"},
+		{false, `{"tiles": ["."], "tile_kinds": {".": {}}, "start": {"x": 3}}`, "start 3,0 is off the map"},
+		{false, `{"tiles": ["."], "tile_kinds": {".": {}}, "things": [{"kind": "ghost", "glyph": "G"}]}`, `things 0 (""): unknown kind "ghost"`},
+		{false, `{"tiles": ["."], "tile_kinds": {".": {}}, "things": [{"kind": "door", "glyph": "+", "state": "ajar"}]}`, `unknown door state "ajar"`},
+		{false, `{"tiles": ["."], "tile_kinds": {".": {}}, "walls": []}`, `unknown field "walls"`},
+	}
+	for _, test := range tests {
+		var err error
+		if test.ascii {
+			_, err = ParseLevelASCII(strings.NewReader(test.level), testContent(t))
+		} else {
+			_, err = ParseLevelJSON(strings.NewReader(test.level), testContent(t))
+		}
+		if err == nil || !strings.Contains(err.Error(), test.want) {
+			t.Errorf("Parsing %s = %v, want an error containing %q", test.level, err, test.want)
+		}
+	}
+}
+
+func TestNewGameOnLevelMap(t *testing.T) {
+	m, err := ParseLevelASCII(strings.NewReader(testLevelMap), testContent(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	config := DefaultGameConfig(1, testContent(t))
+	config.Generator = LevelGenerators{m, config.Generator}
+	config.Populate = nil
+	config.NewUI = NewHeadlessUI(20, 10, NewScriptedActions())
+	game, err := NewGame(log.New(ioutil.Discard, "", 0), config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer game.Close()
+	if loc := game.Player().Loc(); loc != (Vector{1, 1}) {
+		t.Errorf("Player started at %s, want the map's start", loc)
+	}
+	if mobs := game.currentDungeon.Mobs(); len(mobs) != 2 {
+		t.Errorf("Level has %d Mobs, want the Player and the orc", len(mobs))
+	}
+}
@@ -0,0 +1,114 @@
+package gorl
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/nsf/termbox-go"
+)
+
+// A Light is the color and brightness of light, with each of its channels
+// from 0 for none to 1 for full.
+type Light struct {
+	R, G, B float64
+}
+
+// Light colors used by the game itself
+var (
+	WhiteLight = Light{1, 1, 1}
+	FireLight  = Light{1, 0.6, 0.2}
+)
+
+const (
+	// DimLight is the brightness below which a Tile is dim
+	DimLight = 0.25
+	// DimSightRange is how far away anything in a dim Tile can be made out
+	DimSightRange = 2
+)
+
+func (l Light) String() string {
+	return fmt.Sprintf("<Light %.2f,%.2f,%.2f>", l.R, l.G, l.B)
+}
+
+// Add blends two Lights falling on the same place, no brighter than full
+func (l Light) Add(other Light) Light {
+	return Light{
+		math.Min(l.R+other.R, 1),
+		math.Min(l.G+other.G, 1),
+		math.Min(l.B+other.B, 1),
+	}
+}
+
+// Scale returns the Light at scale times the brightness
+func (l Light) Scale(scale float64) Light {
+	return Light{l.R * scale, l.G * scale, l.B * scale}
+}
+
+// Brightness is the brightness of the Light's brightest channel
+func (l Light) Brightness() float64 {
+	return math.Max(l.R, math.Max(l.G, l.B))
+}
+
+// falloff is how much of a light with radius is left distance away from it:
+// all of it at its source, fading evenly to nothing at radius.
+func falloff(distance uint, radius int) float64 {
+	return 1 - float64(distance)/float64(radius)
+}
+
+// MinTint is how much of its own color anything keeps, however dimly it's lit
+const MinTint = 0.25
+
+// basicColors are the colors of termbox's basic color Attributes, with
+// ColorDefault taken to be white.
+var basicColors = map[termbox.Attribute]Light{
+	termbox.ColorDefault: {0.75, 0.75, 0.75},
+	termbox.ColorBlack:   {0, 0, 0},
+	termbox.ColorRed:     {0.75, 0, 0},
+	termbox.ColorGreen:   {0, 0.75, 0},
+	termbox.ColorYellow:  {0.75, 0.75, 0},
+	termbox.ColorBlue:    {0, 0, 0.75},
+	termbox.ColorMagenta: {0.75, 0, 0.75},
+	termbox.ColorCyan:    {0, 0.75, 0.75},
+	termbox.ColorWhite:   {0.75, 0.75, 0.75},
+}
+
+// tint returns color lit by light, as one of the 256 color mode's 6x6x6
+// color cube. Bold colors are brighter, rather than bold. Colors that aren't
+// basic ones are left alone.
+func tint(color termbox.Attribute, light Light) termbox.Attribute {
+	const attributes = termbox.AttrUnderline | termbox.AttrReverse
+	base, ok := basicColors[color&^(termbox.AttrBold|attributes)]
+	if !ok {
+		return color
+	}
+	if color&termbox.AttrBold != 0 {
+		base = base.Scale(4.0 / 3)
+	}
+	channel := func(base, light float64) int {
+		return int(math.Round(5 * base * (MinTint + (1-MinTint)*light)))
+	}
+	index := 16 + 36*channel(base.R, light.R) + 6*channel(base.G, light.G) + channel(base.B, light.B)
+	// Colors in 256 color mode are one more than their index in the palette
+	return termbox.Attribute(index+1) | color&attributes
+}
+
+// RememberedColor is what everything the Player remembers, but can't see
+// now, is drawn in: dark grey, so it's never mistaken for anything they can
+// see, however dimly.
+const RememberedColor = termbox.ColorBlack | termbox.AttrBold
+
+// remembered256 is RememberedColor in 256 color mode, a grey from the
+// palette, as the terminal's bold black might not be grey at all
+const remembered256 = termbox.Attribute(240 + 1)
+
+// shade returns color as it looks on tile, which the Player can see: tinted by
+// the light there in 256 color mode, or dulled if it's dim otherwise.
+func shade(tile *Tile, color termbox.Attribute, colors256 bool) termbox.Attribute {
+	if colors256 {
+		return tint(color, tile.Light())
+	}
+	if tile.Dim() {
+		return color &^ termbox.AttrBold
+	}
+	return color
+}
@@ -0,0 +1,107 @@
+package gorl
+
+import (
+	"math"
+	"testing"
+
+	"github.com/nsf/termbox-go"
+)
+
+func TestCalculateLighting(t *testing.T) {
+	d := newTestDungeon(
+		"........#.",
+	)
+	red, blue := NewFeature("red lamp", '*'), NewFeature("blue lamp", '*')
+	red.SetLightRadius(4)
+	red.SetLightColor(Light{1, 0, 0})
+	red.SetLoc(Vector{0, 0})
+	blue.SetLightRadius(5)
+	blue.SetLightColor(Light{0, 0, 1})
+	blue.SetLoc(Vector{6, 0})
+	d.AddFeature(red)
+	d.AddFeature(blue)
+	d.CalculateLighting()
+
+	tests := []struct {
+		loc  Vector
+		want Light
+	}{
+		{Vector{0, 0}, Light{1, 0, 0}},
+		{Vector{1, 0}, Light{0.75, 0, 0}},
+		{Vector{2, 0}, Light{0.5, 0, 0.2}},
+		{Vector{3, 0}, Light{0.25, 0, 0.4}},
+		{Vector{4, 0}, Light{0, 0, 0.6}},
+		{Vector{6, 0}, Light{0, 0, 1}},
+		// the wall is lit, but not what's behind it
+		{Vector{8, 0}, Light{0, 0, 0.6}},
+		{Vector{9, 0}, Light{}},
+	}
+	for _, test := range tests {
+		got := d.Tile(test.loc).Light()
+		if math.Abs(got.R-test.want.R) > 1e-9 || math.Abs(got.G-test.want.G) > 1e-9 || math.Abs(got.B-test.want.B) > 1e-9 {
+			t.Errorf("Light at %s = %s; want %s", test.loc, got, test.want)
+		}
+		if lit := d.Tile(test.loc).Lit(); lit != (test.want != Light{}) {
+			t.Errorf("Lit() at %s = %t", test.loc, lit)
+		}
+	}
+	if !d.Tile(Vector{9, 0}).Dim() || d.Tile(Vector{3, 0}).Dim() {
+		t.Errorf("Dim() at (9, 0), (3, 0) = %t, %t; want true, false", d.Tile(Vector{9, 0}).Dim(), d.Tile(Vector{3, 0}).Dim())
+	}
+
+	// Lights don't get any brighter than full
+	if got := (Light{0.75, 0.5, 0}).Add(Light{0.5, 0.25, 0}); got != (Light{1, 0.75, 0}) {
+		t.Errorf("Add() = %s; want full red", got)
+	}
+}
+
+func TestCalculateLightingAfterChanges(t *testing.T) {
+	d := newTestDungeon(
+		"......",
+	)
+	d.CalculateLighting()
+	// Looking around mustn't leave FeatureGroups behind
+	for x := 0; x < 6; x++ {
+		d.MobAt(Vector{x, 0})
+		d.ItemsAt(Vector{x, 0})
+	}
+	if len(d.features) != 0 {
+		t.Errorf("%d FeatureGroups after reads; want 0", len(d.features))
+	}
+
+	lamp := NewFeature("lamp", '*')
+	lamp.SetLightRadius(2)
+	lamp.SetLightColor(WhiteLight)
+	lamp.SetLoc(Vector{5, 0})
+	d.AddFeature(lamp)
+	d.CalculateLighting()
+	if !d.Tile(Vector{4, 0}).Lit() || d.Tile(Vector{2, 0}).Lit() {
+		t.Errorf("Lit() at (4, 0), (2, 0) = %t, %t; want true, false", d.Tile(Vector{4, 0}).Lit(), d.Tile(Vector{2, 0}).Lit())
+	}
+}
+
+func TestTint(t *testing.T) {
+	tests := []struct {
+		color termbox.Attribute
+		light Light
+		want  termbox.Attribute
+	}{
+		// 16 + 36*5 + 6*5 + 5, plus one
+		{termbox.ColorWhite | termbox.AttrBold, WhiteLight, 232},
+		{termbox.ColorWhite, Light{}, 16 + 36*1 + 6*1 + 1 + 1},
+		{termbox.ColorYellow | termbox.AttrBold, Light{1, 0, 0}, 16 + 36*5 + 6*1 + 1},
+		{termbox.ColorRed | termbox.AttrUnderline, WhiteLight, (16 + 36*4 + 1) | termbox.AttrUnderline},
+		// Not a basic color
+		{termbox.ColorLightGray, WhiteLight, termbox.ColorLightGray},
+	}
+	for _, test := range tests {
+		if got := tint(test.color, test.light); got != test.want {
+			t.Errorf("tint(%d, %s) = %d; want %d", test.color, test.light, got, test.want)
+		}
+	}
+
+	tile := NewTile('.', termbox.ColorWhite, FlagCrossable)
+	if got := shade(&tile, termbox.ColorWhite|termbox.AttrBold, false); got != termbox.ColorWhite {
+		t.Errorf("shade() in the dark = %d; want %d", got, termbox.ColorWhite)
+	}
+}
@@ -0,0 +1,58 @@
+package gorl
+
+import (
+	"sort"
+
+	"github.com/nsf/termbox-go"
+)
+
+// A Memory is the Feature or Item the Player last saw on a Tile.
+type Memory struct {
+	Char  rune
+	Color termbox.Attribute
+	Name  string
+}
+
+// Remember updates the Player's memory of loc with whatever Feature or Item
+// is on top there now. Mobs move about too much to be worth remembering.
+func (d *Dungeon) Remember(loc Vector) {
+	fg := d.featuresAt(loc)
+	var top Feature
+	if fg.feature != nil {
+		top = fg.feature
+	} else if len(fg.items) > 0 {
+		top = fg.items[len(fg.items)-1]
+	}
+	if top == nil {
+		delete(d.memories, loc)
+		return
+	}
+	d.memories[loc] = Memory{top.Char(), top.Color(), top.Name()}
+}
+
+// Memory returns what the Player remembers at loc, or false if they don't
+// remember anything there.
+func (d *Dungeon) Memory(loc Vector) (Memory, bool) {
+	memory, ok := d.memories[loc]
+	return memory, ok
+}
+
+// memoryRecord is the saved form of a Memory
+type memoryRecord struct {
+	Loc    vectorRecord
+	Memory Memory
+}
+
+// memoryRecords returns every Memory in the Dungeon, sorted by location.
+func (d *Dungeon) memoryRecords() []memoryRecord {
+	locs := make(vectorsByRow, 0, len(d.memories))
+	for loc := range d.memories {
+		locs = append(locs, loc)
+	}
+	sort.Sort(locs)
+	records := make([]memoryRecord, len(locs))
+	for i, loc := range locs {
+		records[i] = memoryRecord{newVectorRecord(loc), d.memories[loc]}
+	}
+	return records
+}
@@ -6,6 +6,7 @@ import (
 	"log"
 	"math/rand"
 
+	"github.com/RWJMurphy/gorl/lib/roll"
 	"github.com/nsf/termbox-go"
 )
 
@@ -15,9 +16,30 @@ type Mob interface {
 	Attacker
 	Defender
 	Wielder
+	Wearer
 
 	SetVisionRadius(int)
 	VisionRadius() int
+	SetBlocksLight(bool)
+	SetSpeed(uint)
+	Speed() uint
+	Dungeon() *Dungeon
+	SetDungeon(*Dungeon)
+	Health() uint
+	MaxHealth() uint
+	SetMaxHealth(uint)
+	Heal(uint) uint
+	SetBaseDamage([]DamageRoll)
+	SetResistance(DamageType, int)
+	AddOnHit(Effect)
+	Effects() []Effect
+	HasEffect(EffectKind) bool
+	TickEffects() EffectTick
+	SetAccuracy(int)
+	SetEvasion(int)
+	AI() AI
+	SetAI(AI)
+	SetCorpse(Item)
 	Move(Vector)
 	Tick(uint, *rand.Rand) MobAction
 
@@ -26,25 +48,54 @@ type Mob interface {
 	DropItem(Item, *Dungeon) bool
 	RemoveFromInventory(Item) bool
 
-	MoveOrAct(Vector) bool
+	MoveOrAct(Vector, *rand.Rand) bool
+}
+
+func init() {
+	RegisterFeatureType("mob", &mob{}, mobRecord{},
+		func(f Feature, s *Saver) (interface{}, error) {
+			return f.(*mob).record(s)
+		},
+		func(record interface{}, l *Loader) (Feature, error) {
+			m := NewMob("", 0, l.log, nil).(*mob)
+			err := m.restore(record.(mobRecord), l)
+			return m, err
+		},
+	)
 }
 
 type mob struct {
 	feature
 	visionRadius int
 	inventory    []Item
-	// XXX I expect this will come out of sync. Head's up, future Reed.
-	dungeon    *Dungeon
-	lastTicked uint
+	// dungeon is kept up to date by Dungeon.AddMob
+	dungeon *Dungeon
+	speed   uint
+	ai      AI
+	// lastSeen is where a hunting Mob last saw an enemy, if chasing is set
+	lastSeen Vector
+	chasing  bool
+	// corpse is dropped when the Mob dies, if it's not nil
+	corpse Item
 
 	// Defender
 	maxHealth uint
 	health    uint
 	// Attacker
-	baseAttack uint
+	baseDamage []DamageRoll
+	accuracy   int
+	onHit      []Effect
+	// Defender
+	evasion     int
+	resistances Resistances
 	// Wielder
 	wieldPoints []string
 	wielding    []Wieldable
+	// Wearer
+	wearSlots []string
+	wearing   []Wearable
+
+	effects []Effect
 
 	fov []Vector
 
@@ -52,12 +103,64 @@ type mob struct {
 }
 
 const MobDefaultHealth = 10
-const MobDefaultAttack = 2
+
+// MobDefaultDamage is how hard a Mob hits with no weapon
+var MobDefaultDamage = []DamageRoll{{DamageBlunt, roll.MustParse("1d3")}}
+
+// MobDefaultSpeed is the speed of an ordinary Mob. A Mob with twice this speed
+// gets two actions for every one an ordinary Mob gets.
+const MobDefaultSpeed = 100
+
+// AI is how a Mob decides what to do on its turn
+type AI uint
+
+const (
+	// AIHunter chases and attacks anything it sees, and picks up any items
+	// it sees, wandering at random otherwise
+	AIHunter AI = iota
+	// AIWanderer wanders at random, minding its own business
+	AIWanderer
+	// AIStationary stays put, attacking anything that comes next to it
+	AIStationary
+)
+
+var aiNames = map[AI]string{
+	AIHunter:     "hunter",
+	AIWanderer:   "wanderer",
+	AIStationary: "stationary",
+}
+
+func (ai AI) String() string {
+	if name, ok := aiNames[ai]; ok {
+		return name
+	}
+	return fmt.Sprintf("AI(%d)", ai)
+}
+
+// ParseAI returns the AI called name
+func ParseAI(name string) (AI, error) {
+	for ai, aiName := range aiNames {
+		if aiName == name {
+			return ai, nil
+		}
+	}
+	return AIHunter, fmt.Errorf("Unknown AI %q", name)
+}
+
 var MobDefaultWieldPoints = []string{
 	"right hand",
 	"left hand",
 }
 
+// MobDefaultWearSlots are where a Mob can wear armor. Every piece of armor is
+// worn on one of these.
+var MobDefaultWearSlots = []string{
+	"head",
+	"body",
+	"hands",
+	"feet",
+}
+
 // NewMob creates and returns a new Mob
 func NewMob(name string, char rune, log *log.Logger, dungeon *Dungeon) Mob {
 	m := &mob{}
@@ -66,10 +169,18 @@ func NewMob(name string, char rune, log *log.Logger, dungeon *Dungeon) Mob {
 	m.wieldPoints = make([]string, len(MobDefaultWieldPoints))
 	copy(m.wieldPoints, MobDefaultWieldPoints)
 	m.wielding = make([]Wieldable, len(m.wieldPoints))
+	m.wearSlots = make([]string, len(MobDefaultWearSlots))
+	copy(m.wearSlots, MobDefaultWearSlots)
+	m.wearing = make([]Wearable, len(m.wearSlots))
 	m.log = log
 	m.maxHealth = MobDefaultHealth
 	m.health = m.maxHealth
-	m.baseAttack = MobDefaultAttack
+	m.baseDamage = MobDefaultDamage
+	m.resistances = make(Resistances)
+	m.speed = MobDefaultSpeed
+	m.ai = AIHunter
+	m.corpse = NewItem("corpse", '%', 100)
+	m.corpse.SetColor(termbox.ColorRed)
 	m.dungeon = dungeon
 	return m
 }
@@ -84,14 +195,24 @@ func (m *mob) String() string {
 }
 
 func (m *mob) Tick(turn uint, dice *rand.Rand) MobAction {
-	action := MobAction{ActNone, nil}
 	if m.Dead() {
-		return action
+		return MobAction{ActNone, nil}
 	}
-	if m.lastTicked != turn-1 {
-		m.log.Panicf("%s out of sync! Last ticked: %d, ticking: %d", m, m.lastTicked, turn)
-		return action
+	switch m.ai {
+	case AIWanderer:
+		return m.wander(dice)
+	case AIStationary:
+		return m.standGuard()
+	default:
+		return m.hunt(dice)
 	}
+}
+
+// hunt chases down the first enemy the Mob can see, or failing that picks up
+// the first item it can see, or failing that wanders. Anything far off in the
+// dark goes unnoticed.
+func (m *mob) hunt(dice *rand.Rand) MobAction {
+	action := MobAction{ActNone, nil}
 
 	var (
 		enemies, items []Feature
@@ -100,11 +221,13 @@ func (m *mob) Tick(turn uint, dice *rand.Rand) MobAction {
 		minDistance    uint
 	)
 
-	m.lastTicked = turn
 	m.calculateFOV()
 
 	for _, loc := range m.fov {
-		fg := m.dungeon.FeatureGroup(loc)
+		if !m.canMakeOut(loc) {
+			continue
+		}
+		fg := m.dungeon.featuresAt(loc)
 		if fg.mob != nil && fg.mob != m {
 			enemies = append(enemies, fg.mob)
 		}
@@ -120,30 +243,25 @@ func (m *mob) Tick(turn uint, dice *rand.Rand) MobAction {
 		focus = enemies[0]
 		action.action = ActMove
 		minDistance = 1
+		m.lastSeen, m.chasing = focus.Loc(), true
+	} else if m.chasing && m.lastSeen != m.Loc() {
+		// Head for wherever the enemy was last seen
+		return m.stepTowards(m.lastSeen)
 	} else if len(items) > 0 {
 		focus = items[0]
 		action.action = ActPickUpAll
 		minDistance = 0
 	}
 
-	if focus != nil {
-		// TODO Pathfinding
-		m.log.Printf("%s@%s focusing on %s@%s", m.Name(), m.Loc(), focus.Name(), focus.Loc())
-		direction = focus.Loc().Sub(m.Loc())
-		if direction.Distance() > minDistance {
-			action.action = ActMove
-			direction = direction.Unit()
-		}
-	} else {
-		m.log.Printf("%s moving randomly", m.Name())
-		// Random movement would be better implemented by selecting from
-		// a list of valid directions
-		direction = Vector{dice.Intn(3) - 1, dice.Intn(3) - 1}
-		if direction.Distance() == 0 {
-			action.action = ActNone
-		} else {
-			action.action = ActMove
-		}
+	m.chasing = len(enemies) > 0
+	if focus == nil {
+		return m.wander(dice)
+	}
+
+	m.log.Printf("%s@%s focusing on %s@%s", m.Name(), m.Loc(), focus.Name(), focus.Loc())
+	direction = focus.Loc().Sub(m.Loc())
+	if direction.Distance() > minDistance {
+		return m.stepTowards(focus.Loc())
 	}
 
 	action.target = direction
@@ -151,9 +269,65 @@ func (m *mob) Tick(turn uint, dice *rand.Rand) MobAction {
 	return action
 }
 
+// stepOrOpen moves the Mob a step in direction, or opens the closed Door
+// that's in the way.
+func (m *mob) stepOrOpen(direction Vector) MobAction {
+	if door, ok := m.dungeon.FeatureAt(m.Loc().Add(direction)).(Door); ok && door.State() == DoorClosed {
+		return MobAction{ActOpen, direction}
+	}
+	return MobAction{ActMove, direction}
+}
+
+// wander moves the Mob a step in a random direction, or not at all.
+func (m *mob) wander(dice *rand.Rand) MobAction {
+	m.log.Printf("%s moving randomly", m.Name())
+	// Random movement would be better implemented by selecting from
+	// a list of valid directions
+	direction := Vector{dice.Intn(3) - 1, dice.Intn(3) - 1}
+	if direction.Distance() == 0 {
+		return MobAction{ActNone, nil}
+	}
+	return MobAction{ActMove, direction}
+}
+
+// standGuard attacks the first Mob next to this one, and otherwise waits.
+func (m *mob) standGuard() MobAction {
+	for _, direction := range pathDirections {
+		if other := m.dungeon.MobAt(m.Loc().Add(direction)); other != nil && !other.Dead() {
+			return MobAction{ActMove, direction}
+		}
+	}
+	return MobAction{ActWait, nil}
+}
+
+// MobPathOptions are used by Mobs when pathing. Other Mobs are expensive to
+// path through, but don't block the way, and Mobs know how to open doors.
+var MobPathOptions = PathOptions{MobCost: 10, OpenDoors: true}
+
+// stepTowards takes the first step along a path to goal, or a step straight
+// towards it if there is no path. Paths may lead through other Mobs, but
+// stepping into one would attack it, so the Mob waits for it to move instead
+// unless it's at goal.
+func (m *mob) stepTowards(goal Vector) MobAction {
+	step := goal.Sub(m.Loc()).Unit()
+	if path, ok := m.dungeon.FindPath(m.Loc(), goal, MobPathOptions); ok && len(path) > 0 {
+		step = path[0].Sub(m.Loc())
+	}
+	if next := m.Loc().Add(step); next != goal && m.dungeon.MobAt(next) != nil {
+		return MobAction{ActWait, nil}
+	}
+	return m.stepOrOpen(step)
+}
+
+// canMakeOut returns true if the Mob can make out what's at loc, which it
+// can't from far off in the dark.
+func (m *mob) canMakeOut(loc Vector) bool {
+	return !m.dungeon.Tile(loc).Dim() || loc.Sub(m.loc).Distance() <= DimSightRange
+}
+
 func (m *mob) calculateFOV() {
 	var fov []Vector
-	m.dungeon.OnTilesInLineOfSight(m.loc, m.visionRadius, func(t *Tile, loc Vector) {
+	m.dungeon.OnTilesInLineOfSight(m.loc, m.VisionRadius(), func(t *Tile, loc Vector) {
 		fov = append(fov, loc)
 	})
 	m.fov = fov
@@ -163,23 +337,64 @@ func (m *mob) SetVisionRadius(r int) {
 	m.visionRadius = r
 }
 
+// VisionRadius is how far the Mob can see, which is nowhere if it's blind
 func (m *mob) VisionRadius() int {
+	if m.HasEffect(EffectBlind) {
+		return 0
+	}
 	return m.visionRadius
 }
 
+func (m *mob) SetSpeed(speed uint) {
+	m.speed = speed
+}
+
+// Speed is the Mob's speed, sped up by any haste
+func (m *mob) Speed() uint {
+	haste := m.effectMagnitude(EffectHaste)
+	if haste <= -100 {
+		return 1
+	}
+	return m.speed * uint(100+haste) / 100
+}
+
+func (m *mob) Dungeon() *Dungeon {
+	return m.dungeon
+}
+
+func (m *mob) SetDungeon(d *Dungeon) {
+	m.dungeon = d
+}
+
 func (m *mob) Move(movement Vector) {
 	m.loc.x += movement.x
 	m.loc.y += movement.y
 }
 
 func (m *mob) LightRadius() int {
-	max := m.feature.LightRadius()
+	radius, _ := m.lightSource()
+	return radius
+}
+
+func (m *mob) LightColor() Light {
+	_, color := m.lightSource()
+	return color
+}
+
+// lightSource returns the radius and color of the furthest reaching light
+// the Mob gives off: its own, the fire it's burning with, or an Item it's
+// carrying.
+func (m *mob) lightSource() (int, Light) {
+	radius, color := m.feature.LightRadius(), m.feature.LightColor()
+	if m.HasEffect(EffectBurning) && BurningLightRadius > radius {
+		radius, color = BurningLightRadius, FireLight
+	}
 	for _, i := range m.inventory {
-		if i.LightRadius() > max {
-			max = i.LightRadius()
+		if i.LightRadius() > radius {
+			radius, color = i.LightRadius(), i.LightColor()
 		}
 	}
-	return max
+	return radius, color
 }
 
 func (m *mob) Inventory() []Item {
@@ -224,27 +439,99 @@ func (m *mob) InventoryIndex(item Item) (int, error) {
 }
 
 func (m *mob) die() {
-	// on death, drop corpse, inventory
-	corpse := NewItem("corpse", '%', 100)
-	corpse.SetColor(termbox.ColorRed)
-	m.AddToInventory(corpse)
-	m.DropItem(corpse, m.dungeon)
-	m.log.Printf("%s dropped %s on death", m.Name(), corpse)
+	// on death, drop corpse, inventory, and everything wielded or worn
+	if m.corpse != nil {
+		m.AddToInventory(m.corpse)
+		m.DropItem(m.corpse, m.dungeon)
+		m.log.Printf("%s dropped %s on death", m.Name(), m.corpse)
+	}
+	for slot, weapon := range m.wielding {
+		if weapon != nil {
+			m.Unwield(uint(slot))
+		}
+	}
+	for slot, worn := range m.wearing {
+		if worn != nil {
+			m.Unequip(uint(slot))
+		}
+	}
 	for _, item := range m.Inventory() {
 		m.DropItem(item, m.dungeon)
 		m.log.Printf("%s dropped %s on death", m.Name(), item)
 	}
 }
 
+func (m *mob) Health() uint {
+	return m.health
+}
+
+func (m *mob) MaxHealth() uint {
+	return m.maxHealth
+}
+
+// SetMaxHealth sets the Mob's maximum health, and heals it fully.
+func (m *mob) SetMaxHealth(health uint) {
+	m.maxHealth = health
+	m.health = health
+}
+
+// Heal gives the Mob back up to amount health, without going over its
+// maximum, and returns how much it healed.
+func (m *mob) Heal(amount uint) uint {
+	if m.Dead() {
+		return 0
+	}
+	if amount > m.maxHealth-m.health {
+		amount = m.maxHealth - m.health
+	}
+	m.health += amount
+	return amount
+}
+
+// SetBaseDamage sets how hard the Mob hits with no weapon
+func (m *mob) SetBaseDamage(damage []DamageRoll) {
+	m.baseDamage = damage
+}
+
+// SetResistance sets how much of damageType the Mob shrugs off by itself, as
+// a percentage
+func (m *mob) SetResistance(damageType DamageType, percent int) {
+	m.resistances[damageType] = percent
+}
+
+// SetAccuracy sets how likely the Mob is to hit, before its weapon's bonus
+func (m *mob) SetAccuracy(accuracy int) {
+	m.accuracy = accuracy
+}
+
+// SetEvasion sets how hard the Mob is to hit
+func (m *mob) SetEvasion(evasion int) {
+	m.evasion = evasion
+}
+
+func (m *mob) AI() AI {
+	return m.ai
+}
+
+func (m *mob) SetAI(ai AI) {
+	m.ai = ai
+}
+
+// SetCorpse sets what the Mob leaves behind when it dies. A nil corpse leaves
+// nothing.
+func (m *mob) SetCorpse(corpse Item) {
+	m.corpse = corpse
+}
+
 func (m *mob) Dead() bool {
 	return m.health <= 0
 }
 
-func (m *mob) MoveOrAct(movement Vector) bool {
+func (m *mob) MoveOrAct(movement Vector, dice *rand.Rand) bool {
 	destination := m.Loc().Add(movement)
 	if otherMob := m.dungeon.MobAt(destination); otherMob != nil {
-		if damageDealt, ok := m.Attack(otherMob); ok {
-			m.log.Printf("%s hit %s for %d damage", m.Name(), otherMob.Name(), damageDealt)
+		if result, ok := m.Attack(otherMob, dice); ok {
+			m.log.Printf("%s attacked %s: %+v", m.Name(), otherMob.Name(), result)
 			if otherMob.Dead() {
 				m.log.Printf("The %s dies!", otherMob.Name())
 			}
@@ -257,17 +544,88 @@ func (m *mob) MoveOrAct(movement Vector) bool {
 	return true
 }
 
-// Defender
-func (m *mob) AttackStrength() uint {
+// weapon returns the first weapon the Mob is wielding, or nil
+func (m *mob) weapon() Wieldable {
 	for _, weapon := range m.wielding {
 		if weapon != nil {
-			return weapon.AttackStrength()
+			return weapon
+		}
+	}
+	return nil
+}
+
+// Damage is what the Mob's weapon rolls for damage, or its base damage if it
+// isn't wielding anything
+func (m *mob) Damage() []DamageRoll {
+	if weapon := m.weapon(); weapon != nil {
+		return weapon.Damage()
+	}
+	return m.baseDamage
+}
+
+// Accuracy is the Mob's own accuracy, plus its weapon's and any might
+func (m *mob) Accuracy() int {
+	accuracy := m.accuracy + m.effectMagnitude(EffectMight)
+	if weapon := m.weapon(); weapon != nil {
+		accuracy += weapon.Accuracy()
+	}
+	return accuracy
+}
+
+// OnHit is the effects the Mob's weapon puts on anything it damages, or the
+// Mob's own if it isn't wielding anything
+func (m *mob) OnHit() []Effect {
+	if weapon := m.weapon(); weapon != nil {
+		return weapon.OnHit()
+	}
+	return m.onHit
+}
+
+// AddOnHit makes the Mob put effect on anything it damages without a weapon
+func (m *mob) AddOnHit(effect Effect) {
+	m.onHit = append(m.onHit, effect)
+}
+
+// Defender
+func (m *mob) Evasion() int {
+	return m.evasion
+}
+
+// Resistance is the Mob's own resistance to damageType, plus the resistance
+// of everything it's wearing
+func (m *mob) Resistance(damageType DamageType) int {
+	resistance := m.resistances[damageType]
+	for _, worn := range m.wearing {
+		if worn != nil {
+			resistance += worn.Resistances()[damageType]
 		}
 	}
-	return m.baseAttack
+	return resistance
 }
 
-func (m *mob) AttackedFor(damage uint) uint {
+// AttackedFor takes the damage in packet. Each type of damage is cut down by
+// the Mob's Resistance to it, and then physical damage by its Defense. Any hit
+// that isn't entirely resisted does at least 1 damage. Returns the damage
+// actually taken.
+func (m *mob) AttackedFor(packet DamagePacket) uint {
+	var damage, resisted uint
+	defense := m.Defense()
+	for _, d := range packet {
+		amount := resist(d.Amount, m.Resistance(d.Type))
+		resisted += amount
+		if d.Type.Physical() {
+			blocked := defense
+			if blocked > amount {
+				blocked = amount
+			}
+			amount -= blocked
+			defense -= blocked
+		}
+		damage += amount
+	}
+	if damage == 0 && resisted > 0 {
+		damage = 1
+	}
 	if damage >= m.health {
 		m.health = 0
 		m.die()
@@ -278,12 +636,11 @@ func (m *mob) AttackedFor(damage uint) uint {
 }
 
 // Attacker
-func (m *mob) Attack(d Defender) (uint, bool) {
+func (m *mob) Attack(d Defender, dice *rand.Rand) (AttackResult, bool) {
 	if !d.Dead() {
-		damageDealt := d.AttackedFor(m.AttackStrength())
-		return damageDealt, true
+		return resolveAttack(m, d, dice), true
 	}
-	return 0, false
+	return AttackResult{}, false
 }
 
 // Wielder
@@ -324,3 +681,286 @@ func (m *mob) Unwield(slot uint) bool {
 	m.wielding[slot] = nil
 	return true
 }
+
+// Wearer
+func (m *mob) WearSlots() []string {
+	return m.wearSlots
+}
+
+func (m *mob) Wearing() []Wearable {
+	return m.wearing
+}
+
+// Defense is the total defense of everything the Mob is wearing
+func (m *mob) Defense() uint {
+	var defense uint
+	for _, worn := range m.wearing {
+		if worn != nil {
+			defense += worn.Defense()
+		}
+	}
+	return defense
+}
+
+// wearSlot returns the index of the slot called name, or -1 if the Mob has no
+// such slot.
+func (m *mob) wearSlot(name string) int {
+	for i, slot := range m.wearSlots {
+		if slot == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// Equip puts on armor from the Mob's inventory, in the armor's slot
+func (m *mob) Equip(armor Wearable) bool {
+	if _, err := m.InventoryIndex(armor); err != nil {
+		m.log.Println(err)
+		return false
+	}
+	slot := m.wearSlot(armor.Slot())
+	if slot < 0 {
+		m.log.Printf("%s tried to wear %s, but has no %s slot", m, armor, armor.Slot())
+		return false
+	}
+	if m.wearing[slot] != nil {
+		m.log.Printf(
+			"%s tried to wear %s on %s, but is already wearing %s",
+			m, armor, armor.Slot(), m.wearing[slot],
+		)
+		return false
+	}
+	m.RemoveFromInventory(armor)
+	m.wearing[slot] = armor
+	return true
+}
+
+// Unequip takes off whatever is worn in slot, putting it in the Mob's
+// inventory
+func (m *mob) Unequip(slot uint) bool {
+	if int(slot) >= len(m.wearing) || m.wearing[slot] == nil {
+		m.log.Printf(
+			"%s tried to take off slot %d, but is not wearing anything there",
+			m, slot,
+		)
+		return false
+	}
+	m.AddToInventory(m.wearing[slot])
+	m.wearing[slot] = nil
+	return true
+}
+
+// Effects
+func (m *mob) Effects() []Effect {
+	return m.effects
+}
+
+// AddEffect puts effect on the Mob, stacking it with any effect of the same
+// kind the Mob already has
+func (m *mob) AddEffect(effect Effect) {
+	for i, e := range m.effects {
+		if e.Kind == effect.Kind {
+			m.effects[i] = e.stack(effect)
+			return
+		}
+	}
+	m.effects = append(m.effects, effect)
+}
+
+func (m *mob) HasEffect(kind EffectKind) bool {
+	for _, e := range m.effects {
+		if e.Kind == kind {
+			return true
+		}
+	}
+	return false
+}
+
+// effectMagnitude is the magnitude of the Mob's effect of kind, or 0 if it
+// doesn't have one
+func (m *mob) effectMagnitude(kind EffectKind) int {
+	for _, e := range m.effects {
+		if e.Kind == kind {
+			return e.Magnitude
+		}
+	}
+	return 0
+}
+
+// TickEffects runs a turn of the Mob's effects: damage and healing over time
+// are dealt, and every effect gets a turn shorter.
+func (m *mob) TickEffects() EffectTick {
+	var (
+		tick   EffectTick
+		packet DamagePacket
+	)
+	if m.Dead() {
+		return tick
+	}
+	remaining := m.effects[:0]
+	for _, e := range m.effects {
+		var amount uint
+		if e.Magnitude > 0 {
+			amount = uint(e.Magnitude)
+		}
+		switch e.Kind {
+		case EffectPoison:
+			packet = append(packet, Damage{DamagePoison, amount})
+		case EffectBurning:
+			packet = append(packet, Damage{DamageFire, amount})
+		case EffectRegeneration:
+			tick.Healed += amount
+		}
+		if e.Turns > 1 {
+			e.Turns--
+			remaining = append(remaining, e)
+		} else {
+			tick.Expired = append(tick.Expired, e)
+		}
+	}
+	m.effects = remaining
+
+	tick.Healed = m.Heal(tick.Healed)
+	if len(packet) > 0 {
+		tick.Damage = m.AttackedFor(packet)
+	}
+	return tick
+}
+
+// mobRecord is the saved form of a mob
+type mobRecord struct {
+	Feature      featureRecord
+	VisionRadius int
+	Speed        uint
+	AI           AI
+	LastSeen     vectorRecord
+	Chasing      bool
+	Corpse       savedFeature
+	Inventory    []savedFeature
+	MaxHealth    uint
+	Health       uint
+	BaseDamage   []damageRollRecord
+	Accuracy     int
+	Evasion      int
+	Resistances  Resistances
+	OnHit        []Effect
+	Effects      []Effect
+	WieldPoints  []string
+	Wielding     []savedFeature
+	WearSlots    []string
+	Wearing      []savedFeature
+}
+
+func (m *mob) record(s *Saver) (mobRecord, error) {
+	r := mobRecord{
+		Feature:      m.feature.record(),
+		VisionRadius: m.visionRadius,
+		Speed:        m.speed,
+		AI:           m.ai,
+		LastSeen:     newVectorRecord(m.lastSeen),
+		Chasing:      m.chasing,
+		MaxHealth:    m.maxHealth,
+		Health:       m.health,
+		BaseDamage:   newDamageRollRecords(m.baseDamage),
+		Accuracy:     m.accuracy,
+		Evasion:      m.evasion,
+		Resistances:  m.resistances,
+		OnHit:        m.onHit,
+		Effects:      m.effects,
+		WieldPoints:  m.wieldPoints,
+		WearSlots:    m.wearSlots,
+	}
+	var err error
+	if r.Corpse, err = s.SaveFeature(m.corpse); err != nil {
+		return r, err
+	}
+	if r.Inventory, err = s.SaveItems(m.inventory); err != nil {
+		return r, err
+	}
+	r.Wielding = make([]savedFeature, len(m.wielding))
+	for i, w := range m.wielding {
+		if r.Wielding[i], err = s.SaveFeature(w); err != nil {
+			return r, err
+		}
+	}
+	r.Wearing = make([]savedFeature, len(m.wearing))
+	for i, w := range m.wearing {
+		if r.Wearing[i], err = s.SaveFeature(w); err != nil {
+			return r, err
+		}
+	}
+	return r, nil
+}
+
+func (m *mob) restore(r mobRecord, l *Loader) error {
+	m.feature.restore(r.Feature)
+	m.visionRadius = r.VisionRadius
+	m.speed = r.Speed
+	m.ai = r.AI
+	m.lastSeen = r.LastSeen.vector()
+	m.chasing = r.Chasing
+	m.maxHealth = r.MaxHealth
+	m.health = r.Health
+	m.accuracy = r.Accuracy
+	m.evasion = r.Evasion
+	m.onHit = r.OnHit
+	m.effects = r.Effects
+	m.wieldPoints = r.WieldPoints
+
+	baseDamage, err := damageRolls(r.BaseDamage)
+	if err != nil {
+		return err
+	}
+	m.baseDamage = baseDamage
+	m.resistances = r.Resistances
+	if m.resistances == nil {
+		m.resistances = make(Resistances)
+	}
+	corpse, err := l.LoadFeature(r.Corpse)
+	if err != nil {
+		return err
+	}
+	m.corpse = nil
+	if corpse != nil {
+		var ok bool
+		if m.corpse, ok = corpse.(Item); !ok {
+			return fmt.Errorf("%s's corpse %s is not an Item", m, corpse)
+		}
+	}
+	if m.inventory, err = l.LoadItems(r.Inventory); err != nil {
+		return err
+	}
+	m.wielding = make([]Wieldable, len(r.Wielding))
+	for i, saved := range r.Wielding {
+		f, err := l.LoadFeature(saved)
+		if err != nil {
+			return err
+		}
+		if f == nil {
+			continue
+		}
+		w, ok := f.(Wieldable)
+		if !ok {
+			return fmt.Errorf("%s can't wield %s", m, f)
+		}
+		m.wielding[i] = w
+	}
+	m.wearSlots = r.WearSlots
+	m.wearing = make([]Wearable, len(r.Wearing))
+	for i, saved := range r.Wearing {
+		f, err := l.LoadFeature(saved)
+		if err != nil {
+			return err
+		}
+		if f == nil {
+			continue
+		}
+		w, ok := f.(Wearable)
+		if !ok {
+			return fmt.Errorf("%s can't wear %s", m, f)
+		}
+		m.wearing[i] = w
+	}
+	return nil
+}
@@ -0,0 +1,275 @@
+package gorl
+
+import (
+	"container/heap"
+	"math"
+)
+
+// PathOptions control which Tiles a path may cross, and how much they cost.
+type PathOptions struct {
+	// MobCost is the extra cost of stepping through a Mob. If it's zero, Mobs
+	// block the path entirely. The start and goal of a path are never blocked
+	// by Mobs.
+	MobCost int
+	// KnownOnly restricts the path to Tiles the Player has seen.
+	KnownOnly bool
+	// OpenDoors lets the path go through closed, but not locked, Doors,
+	// for a turn's extra cost to open them.
+	OpenDoors bool
+}
+
+// pathStepCost is the cost of moving one Tile in any direction
+const pathStepCost = 1
+
+// pathUnreachable is the distance to any location that can't be reached
+const pathUnreachable = math.MaxInt32
+
+// pathDirections are the neighbours of a location, in the order they're
+// considered. Keeping this fixed keeps pathfinding deterministic.
+var pathDirections = []Vector{
+	MoveNorth,
+	MoveEast,
+	MoveSouth,
+	MoveWest,
+	MoveNorthEast,
+	MoveSouthEast,
+	MoveSouthWest,
+	MoveNorthWest,
+}
+
+// stepCost returns the cost of stepping on to loc, and whether it's possible
+// at all.
+func (d *Dungeon) stepCost(loc Vector, opts PathOptions, endpoint bool) (int, bool) {
+	tile := d.Tile(loc)
+	if !tile.Crossable() {
+		return 0, false
+	}
+	if opts.KnownOnly && !tile.Seen() {
+		return 0, false
+	}
+	cost := pathStepCost
+	// Look the FeatureGroup up directly; FeatureGroup() would create one for
+	// every Tile we search.
+	fg, exists := d.features[loc]
+	if !exists {
+		return cost, true
+	}
+	for _, f := range fg.Each() {
+		if f == Feature(fg.mob) {
+			if endpoint {
+				continue
+			}
+			if opts.MobCost == 0 {
+				return 0, false
+			}
+			cost += opts.MobCost
+		} else if door, ok := f.(Door); ok && opts.OpenDoors && door.State() == DoorClosed {
+			cost += pathStepCost
+		} else if f.Flags()&FlagCrossable == 0 {
+			return 0, false
+		}
+	}
+	return cost, true
+}
+
+// pathNode is a location in a pathfinding search's open set
+type pathNode struct {
+	loc      Vector
+	priority int
+	order    int
+}
+
+type pathQueue []pathNode
+
+func (q pathQueue) Len() int {
+	return len(q)
+}
+
+func (q pathQueue) Less(i, j int) bool {
+	if q[i].priority == q[j].priority {
+		return q[i].order < q[j].order
+	}
+	return q[i].priority < q[j].priority
+}
+
+func (q pathQueue) Swap(i, j int) {
+	q[i], q[j] = q[j], q[i]
+}
+
+func (q *pathQueue) Push(x interface{}) {
+	*q = append(*q, x.(pathNode))
+}
+
+func (q *pathQueue) Pop() interface{} {
+	old := *q
+	n := len(old)
+	node := old[n-1]
+	*q = old[:n-1]
+	return node
+}
+
+// FindPath uses A* to find the cheapest path from start to goal. The returned
+// path excludes start and ends with goal. Returns false if there is no path.
+func (d *Dungeon) FindPath(start, goal Vector, opts PathOptions) ([]Vector, bool) {
+	if start == goal {
+		return []Vector{}, true
+	}
+	if _, ok := d.stepCost(goal, opts, true); !ok {
+		return nil, false
+	}
+
+	cameFrom := make(map[Vector]Vector)
+	costSoFar := map[Vector]int{start: 0}
+	open := &pathQueue{}
+	order := 0
+	heap.Push(open, pathNode{start, 0, order})
+
+	for open.Len() > 0 {
+		current := heap.Pop(open).(pathNode)
+		if current.loc == goal {
+			break
+		}
+		for _, direction := range pathDirections {
+			next := current.loc.Add(direction)
+			cost, ok := d.stepCost(next, opts, next == goal)
+			if !ok {
+				continue
+			}
+			newCost := costSoFar[current.loc] + cost
+			if oldCost, seen := costSoFar[next]; !seen || newCost < oldCost {
+				costSoFar[next] = newCost
+				cameFrom[next] = current.loc
+				order++
+				heap.Push(open, pathNode{
+					next,
+					newCost + int(goal.Sub(next).Distance())*pathStepCost,
+					order,
+				})
+			}
+		}
+	}
+
+	if _, found := cameFrom[goal]; !found {
+		return nil, false
+	}
+	var path []Vector
+	for loc := goal; loc != start; loc = cameFrom[loc] {
+		path = append(path, loc)
+	}
+	for i, j := 0, len(path)-1; i < j; i, j = i+1, j-1 {
+		path[i], path[j] = path[j], path[i]
+	}
+	return path, true
+}
+
+// A DijkstraMap holds the cost of reaching the nearest of a set of goals from
+// every location in a Dungeon. Rolling "downhill" on one leads to a goal.
+type DijkstraMap struct {
+	width, height int
+	distances     [][]int
+}
+
+// DijkstraMap builds a DijkstraMap over the Dungeon towards goals.
+func (d *Dungeon) DijkstraMap(goals []Vector, opts PathOptions) *DijkstraMap {
+	distances := make([][]int, d.height)
+	distancesRaw := make([]int, d.width*d.height)
+	for i := range distancesRaw {
+		distancesRaw[i] = pathUnreachable
+	}
+	for i := range distances {
+		distances[i], distancesRaw = distancesRaw[:d.width], distancesRaw[d.width:]
+	}
+	m := &DijkstraMap{d.width, d.height, distances}
+
+	open := &pathQueue{}
+	order := 0
+	for _, goal := range goals {
+		if !m.inBounds(goal) {
+			continue
+		}
+		if _, ok := d.stepCost(goal, opts, true); !ok {
+			continue
+		}
+		m.distances[goal.y][goal.x] = 0
+		order++
+		heap.Push(open, pathNode{goal, 0, order})
+	}
+
+	for open.Len() > 0 {
+		current := heap.Pop(open).(pathNode)
+		if current.priority > m.distances[current.loc.y][current.loc.x] {
+			continue
+		}
+		for _, direction := range pathDirections {
+			next := current.loc.Add(direction)
+			if !m.inBounds(next) {
+				continue
+			}
+			cost, ok := d.stepCost(next, opts, false)
+			if !ok {
+				continue
+			}
+			newCost := current.priority + cost
+			if newCost < m.distances[next.y][next.x] {
+				m.distances[next.y][next.x] = newCost
+				order++
+				heap.Push(open, pathNode{next, newCost, order})
+			}
+		}
+	}
+	return m
+}
+
+func (m *DijkstraMap) inBounds(loc Vector) bool {
+	return loc.x >= 0 && loc.x < m.width && loc.y >= 0 && loc.y < m.height
+}
+
+// Distance returns the cost of getting from loc to the nearest goal, and false
+// if no goal can be reached.
+func (m *DijkstraMap) Distance(loc Vector) (int, bool) {
+	if !m.inBounds(loc) || m.distances[loc.y][loc.x] == pathUnreachable {
+		return pathUnreachable, false
+	}
+	return m.distances[loc.y][loc.x], true
+}
+
+// Downhill returns the neighbour of loc that is closest to a goal, and false
+// if there is no neighbour closer than loc itself.
+func (m *DijkstraMap) Downhill(loc Vector) (Vector, bool) {
+	best, _ := m.Distance(loc)
+	bestLoc := loc
+	for _, direction := range pathDirections {
+		next := loc.Add(direction)
+		if distance, ok := m.Distance(next); ok && distance < best {
+			best = distance
+			bestLoc = next
+		}
+	}
+	return bestLoc, bestLoc != loc
+}
+
+// Frontier returns every location the Player has seen that can be crossed and
+// borders on a Tile they haven't seen: the edge of the explored Dungeon.
+func (d *Dungeon) Frontier() []Vector {
+	var frontier []Vector
+	for y := 0; y < d.height; y++ {
+		for x := 0; x < d.width; x++ {
+			tile := &d.tiles[y][x]
+			if !tile.Seen() || !tile.Crossable() {
+				continue
+			}
+			loc := Vector{x, y}
+			for _, direction := range pathDirections {
+				next := loc.Add(direction)
+				if next.x < 0 || next.x >= d.width || next.y < 0 || next.y >= d.height {
+					continue
+				}
+				if !d.tiles[next.y][next.x].Seen() {
+					frontier = append(frontier, loc)
+					break
+				}
+			}
+		}
+	}
+	return frontier
+}
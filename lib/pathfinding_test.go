@@ -0,0 +1,118 @@
+package gorl
+
+import (
+	"io/ioutil"
+	"log"
+	"testing"
+
+	"github.com/nsf/termbox-go"
+)
+
+// newTestDungeon builds a Dungeon from rows of '#' walls and '.' floors.
+func newTestDungeon(rows ...string) *Dungeon {
+	d := NewDungeon(len(rows[0]), len(rows), log.New(ioutil.Discard, "", 0))
+	for y, row := range rows {
+		for x, c := range row {
+			if c == '#' {
+				d.tiles[y][x] = NewTile('#', termbox.ColorYellow, FlagBlocksLight)
+			} else {
+				d.tiles[y][x] = NewTile('.', termbox.ColorWhite, FlagCrossable)
+			}
+		}
+	}
+	return d
+}
+
+func TestFindPath(t *testing.T) {
+	d := newTestDungeon(
+		"......",
+		".####.",
+		".#..#.",
+		".#..#.",
+		".####.",
+		"......",
+	)
+	start, goal := Vector{2, 2}, Vector{3, 3}
+	if path, ok := d.FindPath(start, goal, PathOptions{}); !ok || len(path) != 1 || path[0] != goal {
+		t.Errorf("FindPath(%s, %s) = %v, %v; want [%s], true", start, goal, path, ok, goal)
+	}
+
+	start, goal = Vector{0, 0}, Vector{5, 5}
+	path, ok := d.FindPath(start, goal, PathOptions{})
+	if !ok {
+		t.Fatalf("FindPath(%s, %s) found no path", start, goal)
+	}
+	if len(path) != 9 {
+		t.Errorf("FindPath(%s, %s) = %v, want a path of length 9", start, goal, path)
+	}
+	prev := start
+	for _, loc := range path {
+		if loc.Sub(prev).Distance() != 1 {
+			t.Errorf("path step %s -> %s is not a single move", prev, loc)
+		}
+		if !d.Tile(loc).Crossable() {
+			t.Errorf("path crosses uncrossable %s", loc)
+		}
+		prev = loc
+	}
+
+	if path, ok := d.FindPath(Vector{0, 0}, Vector{2, 2}, PathOptions{}); ok {
+		t.Errorf("FindPath into a walled room = %v, want no path", path)
+	}
+}
+
+func TestFindPathMobs(t *testing.T) {
+	d := newTestDungeon(
+		"#####",
+		"#...#",
+		"#####",
+	)
+	blocker := NewMob("blocker", 'b', d.log, d)
+	blocker.SetLoc(Vector{2, 1})
+	d.AddMob(blocker)
+
+	start, goal := Vector{1, 1}, Vector{3, 1}
+	if path, ok := d.FindPath(start, goal, PathOptions{}); ok {
+		t.Errorf("FindPath through a Mob with MobCost 0 = %v, want no path", path)
+	}
+	if _, ok := d.FindPath(start, goal, PathOptions{MobCost: 5}); !ok {
+		t.Errorf("FindPath through a Mob with MobCost 5 found no path")
+	}
+	if path, ok := d.FindPath(start, blocker.Loc(), PathOptions{}); !ok || len(path) != 1 {
+		t.Errorf("FindPath to a Mob = %v, %v; want a single step", path, ok)
+	}
+}
+
+func TestDijkstraMap(t *testing.T) {
+	d := newTestDungeon(
+		".....",
+		".###.",
+		".#...",
+	)
+	m := d.DijkstraMap([]Vector{{2, 2}}, PathOptions{})
+	tests := []struct {
+		loc      Vector
+		distance int
+		ok       bool
+	}{
+		{Vector{2, 2}, 0, true},
+		{Vector{4, 1}, 2, true},
+		{Vector{0, 2}, 7, true},
+		{Vector{1, 1}, pathUnreachable, false},
+	}
+	for _, test := range tests {
+		distance, ok := m.Distance(test.loc)
+		if distance != test.distance || ok != test.ok {
+			t.Errorf("Distance(%s) = %d, %v; want %d, %v", test.loc, distance, ok, test.distance, test.ok)
+		}
+	}
+
+	loc := Vector{0, 2}
+	for steps := 0; loc != (Vector{2, 2}); steps++ {
+		next, ok := m.Downhill(loc)
+		if !ok || steps > 7 {
+			t.Fatalf("Downhill from %s got stuck", loc)
+		}
+		loc = next
+	}
+}
@@ -10,6 +10,20 @@ type Player interface {
 	Mob
 }
 
+func init() {
+	RegisterFeatureType("player", &player{}, playerRecord{},
+		func(f Feature, s *Saver) (interface{}, error) {
+			mobRecord, err := f.(*player).mob.record(s)
+			return playerRecord{mobRecord}, err
+		},
+		func(record interface{}, l *Loader) (Feature, error) {
+			p := &player{*NewMob("", 0, l.log, nil).(*mob)}
+			err := p.mob.restore(record.(playerRecord).Mob, l)
+			return p, err
+		},
+	)
+}
+
 type player struct {
 	mob
 }
@@ -34,6 +48,10 @@ func NewPlayer(log *log.Logger, dungeon *Dungeon) Player {
 // XXX Should I move input handling here? Or does that couple the Player
 // to the UI too closely? Argh.
 func (p *player) Tick(turn uint, dice *rand.Rand) MobAction {
-	p.lastTicked = turn
 	return MobAction{ActNone, nil}
 }
+
+// playerRecord is the saved form of a player
+type playerRecord struct {
+	Mob mobRecord
+}
@@ -0,0 +1,347 @@
+package gorl
+
+import (
+	"fmt"
+	"io"
+	"math/rand"
+
+	"github.com/RWJMurphy/gorl/lib/roll"
+	"github.com/nsf/termbox-go"
+)
+
+// The kinds of place things can be spawned in
+const (
+	// PlaceRoom is an ordinary room
+	PlaceRoom = "room"
+	// PlaceVault is a vault
+	PlaceVault = "vault"
+	// PlaceOpen is anywhere not in a room: corridors, caves and open ground
+	PlaceOpen = "open"
+)
+
+// MinSpawnDistance is the closest monsters are put to where the Player
+// arrives on a level
+const MinSpawnDistance = 10
+
+// How much is put on a level the default size. Bigger or smaller levels get
+// more or less, and there's another group of monsters for every level down.
+const (
+	MonsterGroups = 6
+	FloorItems    = 10
+	LightSources  = 8
+)
+
+// SpawnTries is how many places are tried for each thing spawned before
+// giving up on it
+const SpawnTries = 100
+
+// SpawnWeights are how likely something in a spawn table is to be picked.
+// Its weight goes up by WeightPerDepth for every level below MinDepth, and it
+// isn't picked above MinDepth, or below MaxDepth if there is one. Places
+// multiply its weight in each kind of place, "room", "vault" or "open"; it's
+// as likely anywhere if there are none.
+type SpawnWeights struct {
+	Weight         int            `json:"weight"`
+	WeightPerDepth int            `json:"weight_per_depth"`
+	MinDepth       int            `json:"min_depth"`
+	MaxDepth       *int           `json:"max_depth"`
+	Places         map[string]int `json:"places"`
+}
+
+// A MonsterSpawn is a monster that can be spawned, in groups of Group, a
+// dice expression that's 1 if left out.
+type MonsterSpawn struct {
+	ID    string `json:"id"`
+	Group string `json:"group"`
+	SpawnWeights
+
+	group roll.Dice
+}
+
+// An ItemSpawn is an item that can be left lying about
+type ItemSpawn struct {
+	ID string `json:"id"`
+	SpawnWeights
+}
+
+// A LightSpawn is a Feature that gives off light, like a brazier. Its glyph,
+// color and light are as in ItemTemplate. Nothing can walk through it.
+type LightSpawn struct {
+	Name        string `json:"name"`
+	Glyph       string `json:"glyph"`
+	Color       string `json:"color"`
+	LightRadius int    `json:"light_radius"`
+	LightColor  string `json:"light_color"`
+	SpawnWeights
+
+	char       rune
+	color      termbox.Attribute
+	lightColor Light
+}
+
+// SpawnTables are what PopulateLevel can put on a level
+type SpawnTables struct {
+	Monsters []*MonsterSpawn `json:"monsters"`
+	Items    []*ItemSpawn    `json:"items"`
+	Lights   []*LightSpawn   `json:"lights"`
+}
+
+// LoadSpawns loads and validates the spawn tables in r, a JSON object, which
+// must only name monsters and items in c.
+func (c *Content) LoadSpawns(r io.Reader) error {
+	var spawns SpawnTables
+	if err := decodeContent(r, &spawns); err != nil {
+		return fmt.Errorf("%s: %s", SpawnsFile, err)
+	}
+	for i, s := range spawns.Monsters {
+		if err := s.validate(c); err != nil {
+			return fmt.Errorf("%s: monster %d (%q): %s", SpawnsFile, i, s.ID, err)
+		}
+	}
+	for i, s := range spawns.Items {
+		if err := s.validate(c); err != nil {
+			return fmt.Errorf("%s: item %d (%q): %s", SpawnsFile, i, s.ID, err)
+		}
+	}
+	for i, s := range spawns.Lights {
+		if err := s.validate(); err != nil {
+			return fmt.Errorf("%s: light %d (%q): %s", SpawnsFile, i, s.Name, err)
+		}
+	}
+	c.Spawns = &spawns
+	return nil
+}
+
+func (w SpawnWeights) validate() error {
+	if w.Weight < 0 {
+		return fmt.Errorf("weight %d is negative", w.Weight)
+	}
+	if w.MaxDepth != nil && *w.MaxDepth < w.MinDepth {
+		return fmt.Errorf("max_depth %d is above min_depth %d", *w.MaxDepth, w.MinDepth)
+	}
+	for place, weight := range w.Places {
+		if place != PlaceRoom && place != PlaceVault && place != PlaceOpen {
+			return fmt.Errorf("places: unknown place %q", place)
+		}
+		if weight < 0 {
+			return fmt.Errorf("places: %s weight %d is negative", place, weight)
+		}
+	}
+	return nil
+}
+
+func (s *MonsterSpawn) validate(c *Content) error {
+	var err error
+	if c.Monsters[s.ID] == nil {
+		return fmt.Errorf("unknown monster")
+	}
+	if s.Group == "" {
+		s.Group = "1"
+	}
+	if s.group, err = roll.Parse(s.Group); err != nil {
+		return fmt.Errorf("group: %s", err)
+	}
+	return s.SpawnWeights.validate()
+}
+
+func (s *ItemSpawn) validate(c *Content) error {
+	if c.Items[s.ID] == nil {
+		return fmt.Errorf("unknown item")
+	}
+	return s.SpawnWeights.validate()
+}
+
+func (s *LightSpawn) validate() error {
+	var err error
+	if s.Name == "" {
+		return fmt.Errorf("has no name")
+	}
+	if s.char, err = parseGlyph(s.Glyph); err != nil {
+		return err
+	}
+	if s.color, err = parseColor(s.Color); err != nil {
+		return err
+	}
+	if s.LightRadius <= 0 {
+		return fmt.Errorf("light_radius %d isn't positive", s.LightRadius)
+	}
+	if s.lightColor, err = parseLight(s.LightColor); err != nil {
+		return err
+	}
+	return s.SpawnWeights.validate()
+}
+
+// weight is how likely something is to be picked at depth, in place
+func (w SpawnWeights) weight(depth int, place string) int {
+	if depth < w.MinDepth || (w.MaxDepth != nil && depth > *w.MaxDepth) {
+		return 0
+	}
+	weight := w.Weight + w.WeightPerDepth*(depth-w.MinDepth)
+	if len(w.Places) > 0 {
+		weight *= w.Places[place]
+	}
+	if weight < 0 {
+		return 0
+	}
+	return weight
+}
+
+// pickWeighted returns the index of one of weights, picked in proportion to
+// its weight, or -1 if they're all 0.
+func pickWeighted(weights []int, dice *rand.Rand) int {
+	total := 0
+	for _, weight := range weights {
+		total += weight
+	}
+	if total == 0 {
+		return -1
+	}
+	n := dice.Intn(total)
+	for i, weight := range weights {
+		if n < weight {
+			return i
+		}
+		n -= weight
+	}
+	return -1
+}
+
+// PlaceAt returns what kind of place loc is in: a vault, an ordinary room, or
+// out in the open.
+func (d *Dungeon) PlaceAt(loc Vector) string {
+	place := PlaceOpen
+	for _, room := range d.rooms {
+		if !room.Area.Contains(loc) {
+			continue
+		}
+		if room.Vault != "" {
+			return PlaceVault
+		}
+		place = PlaceRoom
+	}
+	return place
+}
+
+// spawnLoc picks somewhere free on d, with no Feature on it, at least
+// minDistance from where the Player arrives. If open is true, it also has to
+// have floor all round it, so that anything put there can't block the way.
+// Returns false if nowhere was found.
+func (d *Dungeon) spawnLoc(dice *rand.Rand, minDistance uint, open bool) (Vector, bool) {
+	for try := 0; try < SpawnTries; try++ {
+		loc, ok := d.RandomFreeLoc(dice)
+		if !ok {
+			break
+		}
+		if loc.Sub(d.origin).Distance() < minDistance || d.FeatureAt(loc) != nil {
+			continue
+		}
+		if open && !d.openAround(loc) {
+			continue
+		}
+		return loc, true
+	}
+	return Vector{}, false
+}
+
+// openAround returns true if every Tile around loc is floor, with nothing in
+// the way on it.
+func (d *Dungeon) openAround(loc Vector) bool {
+	for _, direction := range pathDirections {
+		next := loc.Add(direction)
+		if !d.Tile(next).Crossable() || d.FeatureAt(next) != nil || !d.featuresAt(next).Crossable() {
+			return false
+		}
+	}
+	return true
+}
+
+// spawnCount is how many of something there are on d, given count on a
+// default sized level, with at least one.
+func spawnCount(d *Dungeon, count int) int {
+	count = count * d.width * d.height / (DefaultLevelWidth * DefaultLevelHeight)
+	if count < 1 {
+		return 1
+	}
+	return count
+}
+
+// PopulateLevel fills a new Dungeon from the Content's spawn tables: groups of
+// monsters, no closer than MinSpawnDistance to where the Player arrives, items
+// lying about, and lights. What's picked depends on how deep the Dungeon is
+// and what kind of place it's put in. Deeper monsters are tougher, too.
+func PopulateLevel(game *Game, d *Dungeon) {
+	content := game.config.Content
+	if content == nil || content.Spawns == nil {
+		return
+	}
+	spawns, dice, depth := content.Spawns, game.dice, d.Depth()
+
+	for i := 0; i < spawnCount(d, MonsterGroups+depth); i++ {
+		loc, ok := d.spawnLoc(dice, MinSpawnDistance, false)
+		if !ok {
+			continue
+		}
+		weights := make([]int, len(spawns.Monsters))
+		for j, s := range spawns.Monsters {
+			weights[j] = s.weight(depth, d.PlaceAt(loc))
+		}
+		j := pickWeighted(weights, dice)
+		if j < 0 {
+			continue
+		}
+		size := spawns.Monsters[j].group.Roll(dice)
+		for k := 0; k < size; k++ {
+			// The rest of the group crowd around the first
+			at, ok := d.FreeLocNear(loc)
+			if !ok || at.Sub(d.origin).Distance() < MinSpawnDistance {
+				break
+			}
+			mob, err := content.NewMonster(spawns.Monsters[j].ID, game.log, d)
+			if err != nil {
+				game.log.Panic(err)
+			}
+			mob.SetMaxHealth(mob.MaxHealth() + uint(depth)*3)
+			mob.SetLoc(at)
+			d.AddMob(mob)
+		}
+	}
+
+	for i := 0; i < spawnCount(d, FloorItems); i++ {
+		loc, ok := d.spawnLoc(dice, 1, false)
+		if !ok {
+			continue
+		}
+		weights := make([]int, len(spawns.Items))
+		for j, s := range spawns.Items {
+			weights[j] = s.weight(depth, d.PlaceAt(loc))
+		}
+		if j := pickWeighted(weights, dice); j >= 0 {
+			item, err := content.NewItem(spawns.Items[j].ID)
+			if err != nil {
+				game.log.Panic(err)
+			}
+			item.SetLoc(loc)
+			d.AddItem(item)
+		}
+	}
+
+	for i := 0; i < spawnCount(d, LightSources); i++ {
+		loc, ok := d.spawnLoc(dice, 1, true)
+		if !ok {
+			continue
+		}
+		weights := make([]int, len(spawns.Lights))
+		for j, s := range spawns.Lights {
+			weights[j] = s.weight(depth, d.PlaceAt(loc))
+		}
+		if j := pickWeighted(weights, dice); j >= 0 {
+			s := spawns.Lights[j]
+			light := NewFeature(s.Name, s.char)
+			light.SetColor(s.color)
+			light.SetLightRadius(s.LightRadius)
+			light.SetLightColor(s.lightColor)
+			light.SetLoc(loc)
+			d.AddFeature(light)
+		}
+	}
+}
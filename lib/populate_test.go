@@ -0,0 +1,116 @@
+package gorl
+
+import (
+	"fmt"
+	"io/ioutil"
+	"log"
+	"math/rand"
+	"strings"
+	"testing"
+)
+
+func TestSpawnWeights(t *testing.T) {
+	maxDepth := 4
+	w := SpawnWeights{
+		Weight:         2,
+		WeightPerDepth: 1,
+		MinDepth:       1,
+		MaxDepth:       &maxDepth,
+		Places:         map[string]int{PlaceRoom: 1, PlaceVault: 3},
+	}
+	tests := []struct {
+		depth int
+		place string
+		want  int
+	}{
+		{0, PlaceRoom, 0},
+		{1, PlaceRoom, 2},
+		{3, PlaceRoom, 4},
+		{3, PlaceVault, 12},
+		{3, PlaceOpen, 0},
+		{5, PlaceRoom, 0},
+	}
+	for _, test := range tests {
+		if got := w.weight(test.depth, test.place); got != test.want {
+			t.Errorf("weight(%d, %s) = %d, want %d", test.depth, test.place, got, test.want)
+		}
+	}
+	if got := (SpawnWeights{Weight: 1}).weight(7, PlaceOpen); got != 1 {
+		t.Errorf("weight with no places = %d, want 1", got)
+	}
+
+	dice := rand.New(rand.NewSource(1))
+	if i := pickWeighted([]int{0, 0}, dice); i != -1 {
+		t.Errorf("pickWeighted picked %d when nothing has any weight", i)
+	}
+	for n := 0; n < 20; n++ {
+		if i := pickWeighted([]int{0, 5, 0}, dice); i != 1 {
+			t.Fatalf("pickWeighted picked %d, want the only one with weight", i)
+		}
+	}
+}
+
+func TestLoadSpawnsErrors(t *testing.T) {
+	tests := []struct {
+		spawns, want string
+	}{
+		{`{"monsters": [{"id": "dragon"}]}`, `spawns.json: monster 0 ("dragon"): unknown monster`},
+		{`{"monsters": [{"id": "orc", "group": "2d"}]}`, `monster 0 ("orc"): group: roll: bad number of sides`},
+		{`{"monsters": [{"id": "orc", "places": {"attic": 1}}]}`, `monster 0 ("orc"): places: unknown place "attic"`},
+		{`{"items": [{"id": "cheese"}]}`, `spawns.json: item 0 ("cheese"): unknown item`},
+		{`{"items": [{"id": "torch", "weight": -1}]}`, `item 0 ("torch"): weight -1 is negative`},
+		{`{"items": [{"id": "torch", "min_depth": 3, "max_depth": 1}]}`, `item 0 ("torch"): max_depth 1 is above min_depth 3`},
+		{`{"lights": [{"name": "lamp", "glyph": "*"}]}`, `light 0 ("lamp"): light_radius 0 isn't positive`},
+		{`{"lights": [{"name": "lamp", "glyph": "*", "light_radius": 2, "light_color": "red"}]}`, `light 0 ("lamp"): light color "red" isn't like #rrggbb`},
+		{`{"traps": []}`, `spawns.json: json: unknown field "traps"`},
+	}
+	for _, test := range tests {
+		err := testContent(t).LoadSpawns(strings.NewReader(test.spawns))
+		if err == nil || !strings.Contains(err.Error(), test.want) {
+			t.Errorf("LoadSpawns(%s) = %v, want an error containing %q", test.spawns, err, test.want)
+		}
+	}
+}
+
+// population describes everything on a level, to compare them
+func population(d *Dungeon) []string {
+	var things []string
+	for _, loc := range d.featureLocs() {
+		for _, f := range d.FeatureGroup(loc).Each() {
+			things = append(things, fmt.Sprintf("%s at %s", f.Name(), loc))
+		}
+	}
+	return things
+}
+
+func TestPopulateLevel(t *testing.T) {
+	logger := log.New(ioutil.Discard, "", 0)
+	config := DefaultGameConfig(1234, testContent(t))
+	config.Width, config.Height = 80, 60
+	d := newGame(logger, config).dungeonAt(2)
+
+	mobs, items, lights := 0, 0, 0
+	for _, loc := range d.featureLocs() {
+		if d.MobAt(loc) != nil {
+			mobs++
+			if distance := loc.Sub(d.origin).Distance(); distance < MinSpawnDistance {
+				t.Errorf("%s is %d from the start", d.MobAt(loc), distance)
+			}
+		}
+		items += len(d.ItemsAt(loc))
+		if f := d.FeatureAt(loc); f != nil && f.LightRadius() > 0 {
+			lights++
+			if !d.openAround(loc) {
+				t.Errorf("%s at %s is in the way", f.Name(), loc)
+			}
+		}
+	}
+	if mobs == 0 || items == 0 || lights == 0 {
+		t.Errorf("Level has %d Mobs, %d items and %d lights, want some of each", mobs, items, lights)
+	}
+
+	again := population(newGame(logger, config).dungeonAt(2))
+	if got := population(d); strings.Join(got, "\n") != strings.Join(again, "\n") {
+		t.Errorf("The same seed populated the level differently:\n%s\n\n%s", strings.Join(got, "\n"), strings.Join(again, "\n"))
+	}
+}
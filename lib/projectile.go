@@ -0,0 +1,113 @@
+package gorl
+
+import (
+	"math/rand"
+
+	"github.com/RWJMurphy/gorl/lib/roll"
+)
+
+// ThrowRange is how far anything can be thrown
+const ThrowRange = 8
+
+// ThrownDamage is what an Item that isn't a weapon does when it's thrown at
+// somebody
+var ThrownDamage = []DamageRoll{{DamageBlunt, roll.MustParse("1d2")}}
+
+// ThrowTarget is the target of an ActThrow: the Item to throw, and where to
+// throw it.
+type ThrowTarget struct {
+	Item Item
+	At   Vector
+}
+
+// line returns the locations on a Bresenham line from from to to, not
+// including from.
+func line(from, to Vector) []Vector {
+	var points []Vector
+	dx, dy := int(IntAbs(to.x-from.x)), -int(IntAbs(to.y-from.y))
+	sx, sy := 1, 1
+	if from.x > to.x {
+		sx = -1
+	}
+	if from.y > to.y {
+		sy = -1
+	}
+	err := dx + dy
+	for p := from; p != to; {
+		e2 := 2 * err
+		if e2 >= dy {
+			err += dy
+			p.x += sx
+		}
+		if e2 <= dx {
+			err += dx
+			p.y += sy
+		}
+		points = append(points, p)
+	}
+	return points
+}
+
+// ProjectilePath returns where something thrown or fired from from at to
+// flies, up to maxRange moves away. It stops at to, at the first Mob in the
+// way, which it returns, or just before anything it can't pass through.
+func (d *Dungeon) ProjectilePath(from, to Vector, maxRange int) ([]Vector, Mob) {
+	var path []Vector
+	for _, loc := range line(from, to) {
+		if loc.Sub(from).Distance() > uint(maxRange) {
+			break
+		}
+		if mob := d.MobAt(loc); mob != nil {
+			return append(path, loc), mob
+		}
+		tile := d.Tile(loc)
+		if !tile.Crossable() || tile.BlocksLight() || !d.featuresAt(loc).Crossable() {
+			break
+		}
+		path = append(path, loc)
+	}
+	return path, nil
+}
+
+// RangedWeapon returns the first weapon mob is wielding that can be fired,
+// or false if it has none.
+func RangedWeapon(mob Mob) (Wieldable, bool) {
+	for _, weapon := range mob.Wielding() {
+		if weapon != nil && weapon.Range() > 0 {
+			return weapon, true
+		}
+	}
+	return nil, false
+}
+
+// thrownAttack is an Item being thrown at somebody. It hits with its
+// thrower's accuracy, and its own damage if it's a weapon.
+type thrownAttack struct {
+	thrower Mob
+	item    Item
+}
+
+func (t thrownAttack) Damage() []DamageRoll {
+	if weapon, ok := t.item.(Wieldable); ok {
+		return weapon.Damage()
+	}
+	return ThrownDamage
+}
+
+func (t thrownAttack) Accuracy() int {
+	return t.thrower.Accuracy()
+}
+
+func (t thrownAttack) OnHit() []Effect {
+	if weapon, ok := t.item.(Wieldable); ok {
+		return weapon.OnHit()
+	}
+	return nil
+}
+
+func (t thrownAttack) Attack(d Defender, dice *rand.Rand) (AttackResult, bool) {
+	if !d.Dead() {
+		return resolveAttack(t, d, dice), true
+	}
+	return AttackResult{}, false
+}
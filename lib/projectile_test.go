@@ -0,0 +1,58 @@
+package gorl
+
+import "testing"
+
+func TestLine(t *testing.T) {
+	tests := []struct {
+		from, to Vector
+		want     []Vector
+	}{
+		{Vector{0, 0}, Vector{3, 0}, []Vector{{1, 0}, {2, 0}, {3, 0}}},
+		{Vector{2, 2}, Vector{0, 0}, []Vector{{1, 1}, {0, 0}}},
+		{Vector{0, 0}, Vector{4, 2}, []Vector{{1, 1}, {2, 1}, {3, 2}, {4, 2}}},
+		{Vector{1, 1}, Vector{1, 1}, nil},
+	}
+	for _, test := range tests {
+		got := line(test.from, test.to)
+		if len(got) != len(test.want) {
+			t.Errorf("line(%s, %s) = %v, want %v", test.from, test.to, got, test.want)
+			continue
+		}
+		for i := range got {
+			if got[i] != test.want[i] {
+				t.Errorf("line(%s, %s) = %v, want %v", test.from, test.to, got, test.want)
+				break
+			}
+		}
+	}
+}
+
+func TestProjectilePath(t *testing.T) {
+	d := newTestDungeon(
+		"#########",
+		"#.......#",
+		"#...#...#",
+		"#########",
+	)
+	orc := NewMob("orc", 'o', d.log, d)
+	orc.SetLoc(Vector{5, 1})
+	d.AddMob(orc)
+
+	tests := []struct {
+		to       Vector
+		maxRange int
+		end      Vector
+		hit      Mob
+	}{
+		{Vector{7, 1}, 10, Vector{5, 1}, orc},
+		{Vector{3, 1}, 10, Vector{3, 1}, nil},
+		{Vector{7, 1}, 2, Vector{3, 1}, nil},
+		{Vector{7, 2}, 10, Vector{3, 1}, nil},
+	}
+	for _, test := range tests {
+		path, hit := d.ProjectilePath(Vector{1, 1}, test.to, test.maxRange)
+		if len(path) == 0 || path[len(path)-1] != test.end || hit != test.hit {
+			t.Errorf("ProjectilePath to %s, range %d = %v, %v; want to end at %s hitting %v", test.to, test.maxRange, path, hit, test.end, test.hit)
+		}
+	}
+}
@@ -45,6 +45,17 @@ func (r Rectangle) Size() Vector {
 	return r.size
 }
 
+// Contains returns true if loc is inside r
+func (r Rectangle) Contains(loc Vector) bool {
+	return loc.x >= r.topLeft.x && loc.y >= r.topLeft.y && loc.x < r.BottomRight().x && loc.y < r.BottomRight().y
+}
+
+// Overlaps returns true if any part of r is inside other
+func (r Rectangle) Overlaps(other Rectangle) bool {
+	return r.topLeft.x < other.BottomRight().x && other.topLeft.x < r.BottomRight().x &&
+		r.topLeft.y < other.BottomRight().y && other.topLeft.y < r.BottomRight().y
+}
+
 func (r Rectangle) String() string {
 	return fmt.Sprintf("<Rectangle topLeft:%s, bottomRight:%s, size:%s>", r.topLeft, r.BottomRight(), r.size)
 }
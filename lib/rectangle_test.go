@@ -95,3 +95,28 @@ func TestRectangle(t *testing.T) {
 		}
 	}
 }
+
+func TestRectangleOverlaps(t *testing.T) {
+	r := Rectangle{Vector{2, 2}, Vector{3, 3}}
+	if !r.Contains(Vector{2, 2}) || !r.Contains(Vector{4, 4}) || r.Contains(Vector{5, 4}) || r.Contains(Vector{1, 3}) {
+		t.Errorf("%s contains the wrong locations", r)
+	}
+	tests := []struct {
+		other Rectangle
+		want  bool
+	}{
+		{Rectangle{Vector{0, 0}, Vector{3, 3}}, true},
+		{Rectangle{Vector{3, 3}, Vector{1, 1}}, true},
+		{Rectangle{Vector{0, 0}, Vector{10, 10}}, true},
+		{Rectangle{Vector{5, 2}, Vector{2, 2}}, false},
+		{Rectangle{Vector{0, 0}, Vector{2, 9}}, false},
+	}
+	for _, test := range tests {
+		if got := r.Overlaps(test.other); got != test.want {
+			t.Errorf("%s.Overlaps(%s) = %t; want %t", r, test.other, got, test.want)
+		}
+		if got := test.other.Overlaps(r); got != test.want {
+			t.Errorf("%s.Overlaps(%s) = %t; want %t", test.other, r, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,226 @@
+package gorl
+
+import (
+	"encoding/gob"
+	"fmt"
+	"io"
+	"log"
+	"time"
+)
+
+// ReplayVersion is the version of the replay format. Replays from any other
+// version are refused.
+const ReplayVersion = 2
+
+// A replayHeader starts every replay, and holds everything needed to recreate
+// the Game the replay was recorded from.
+type replayHeader struct {
+	Version int
+	Seed    int64
+	Width   int
+	Height  int
+	Levels  Levels
+}
+
+type replayTarget int
+
+const (
+	replayTargetNone replayTarget = iota
+	replayTargetVector
+	replayTargetItem
+	replayTargetSlot
+	replayTargetThrow
+)
+
+// A replayRecord is a single action taken by the Player. Items are recorded as
+// their index in the Player's inventory, which is the same on replay as it
+// was when recorded.
+type replayRecord struct {
+	Action mobAction
+	State  GameState
+	Target replayTarget
+	Vector vectorRecord
+	Item   int
+	Slot   uint
+}
+
+func newReplayRecord(action MobAction, state GameState, player Player) (replayRecord, error) {
+	record := replayRecord{action.action, state, replayTargetNone, vectorRecord{}, -1, 0}
+	switch target := action.target.(type) {
+	case nil:
+	case Vector:
+		record.Target = replayTargetVector
+		record.Vector = newVectorRecord(target)
+	case Item:
+		record.Target = replayTargetItem
+		if record.Item = inventoryIndex(player, target); record.Item < 0 {
+			return record, fmt.Errorf("%s is not in the Player's inventory", target)
+		}
+	case ThrowTarget:
+		record.Target = replayTargetThrow
+		record.Vector = newVectorRecord(target.At)
+		if record.Item = inventoryIndex(player, target.Item); record.Item < 0 {
+			return record, fmt.Errorf("%s is not in the Player's inventory", target.Item)
+		}
+	case uint:
+		record.Target = replayTargetSlot
+		record.Slot = target
+	default:
+		return record, fmt.Errorf("Can't record target %v", target)
+	}
+	return record, nil
+}
+
+// inventoryIndex returns where item is in player's inventory, or -1 if it
+// isn't there.
+func inventoryIndex(player Player, item Item) int {
+	for i, held := range player.Inventory() {
+		if held == item {
+			return i
+		}
+	}
+	return -1
+}
+
+func (r replayRecord) mobAction(player Player) (MobAction, error) {
+	action := MobAction{r.Action, nil}
+	switch r.Target {
+	case replayTargetNone:
+	case replayTargetVector:
+		action.target = r.Vector.vector()
+	case replayTargetItem, replayTargetThrow:
+		inventory := player.Inventory()
+		if r.Item < 0 || r.Item >= len(inventory) {
+			return action, fmt.Errorf("Replay wants item %d, but the Player has %d", r.Item, len(inventory))
+		}
+		action.target = inventory[r.Item]
+		if r.Target == replayTargetThrow {
+			action.target = ThrowTarget{inventory[r.Item], r.Vector.vector()}
+		}
+	case replayTargetSlot:
+		action.target = r.Slot
+	default:
+		return action, fmt.Errorf("Bad replay target: %d", r.Target)
+	}
+	return action, nil
+}
+
+// recordingUI passes everything through to another UI, and writes each action
+// the Player takes to a replay.
+type recordingUI struct {
+	UI
+	game    *Game
+	w       io.WriteCloser
+	encoder *gob.Encoder
+}
+
+// NewRecordingUI wraps ui, recording the actions taken through it in game to
+// w. The replay header is written straight away. Closing the UI closes w.
+func NewRecordingUI(ui UI, game *Game, w io.WriteCloser) (UI, error) {
+	r := &recordingUI{ui, game, w, gob.NewEncoder(w)}
+	header := replayHeader{ReplayVersion, game.seed, game.width, game.height, game.config.Levels}
+	if err := r.encoder.Encode(header); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// DoEvent gets an action from the wrapped UI, recording it if it does
+// anything.
+func (r *recordingUI) DoEvent() (MobAction, GameState) {
+	action, state := r.UI.DoEvent()
+	if action.action == ActNone && state != GameClosed {
+		return action, state
+	}
+	record, err := newReplayRecord(action, state, r.game.player)
+	if err == nil {
+		err = r.encoder.Encode(record)
+	}
+	if err != nil {
+		r.game.log.Printf("Couldn't record %s: %s", action, err)
+	}
+	return action, state
+}
+
+// Close closes the replay, then the wrapped UI.
+func (r *recordingUI) Close() {
+	if err := r.w.Close(); err != nil {
+		r.game.log.Printf("Couldn't close replay: %s", err)
+	}
+	r.UI.Close()
+}
+
+// A Replay is a recorded game, ready to be played back.
+type Replay struct {
+	header  replayHeader
+	decoder *gob.Decoder
+}
+
+// OpenReplay reads the header of the replay in r.
+func OpenReplay(r io.Reader) (*Replay, error) {
+	replay := &Replay{decoder: gob.NewDecoder(r)}
+	if err := replay.decoder.Decode(&replay.header); err != nil {
+		return nil, err
+	}
+	if replay.header.Version != ReplayVersion {
+		return nil, fmt.Errorf("Replay is version %d, can only play version %d", replay.header.Version, ReplayVersion)
+	}
+	return replay, nil
+}
+
+// NewGame starts the Game the Replay was recorded from. The seed, level size
+// and, if they were set up by Levels, how levels are made come from the
+// Replay, and everything else from config, which must match the config the
+// Replay was recorded with. To play the Replay back, give it a UI that takes
+// its actions from the Replay: a replay UI or a HeadlessUI.
+func (replay *Replay) NewGame(log *log.Logger, config GameConfig) (*Game, error) {
+	config.Seed = replay.header.Seed
+	config.Width = replay.header.Width
+	config.Height = replay.header.Height
+	if replay.header.Levels.Generators != "" {
+		if err := replay.header.Levels.Configure(&config); err != nil {
+			return nil, err
+		}
+	}
+	return NewGame(log, config)
+}
+
+// NextAction returns the next action in the Replay, implementing
+// ActionSource. Once the Replay runs out, the Game is closed.
+func (replay *Replay) NextAction(game *Game) (MobAction, GameState) {
+	var record replayRecord
+	if err := replay.decoder.Decode(&record); err != nil {
+		if err != io.EOF {
+			game.log.Printf("Couldn't read replay: %s", err)
+		}
+		return MobAction{ActNone, nil}, GameClosed
+	}
+	action, err := record.mobAction(game.player)
+	if err != nil {
+		game.log.Printf("Replay out of sync: %s", err)
+		return MobAction{ActNone, nil}, GameClosed
+	}
+	return action, record.State
+}
+
+// replayUI passes everything through to another UI except for input, which
+// comes from a Replay instead.
+type replayUI struct {
+	UI
+	game   *Game
+	replay *Replay
+	delay  time.Duration
+}
+
+// NewReplayUI wraps ui, feeding the actions in replay to game, one every
+// delay.
+func NewReplayUI(ui UI, game *Game, replay *Replay, delay time.Duration) UI {
+	return &replayUI{ui, game, replay, delay}
+}
+
+// DoEvent returns the next action in the Replay, after waiting for the
+// delay.
+func (r *replayUI) DoEvent() (MobAction, GameState) {
+	time.Sleep(r.delay)
+	return r.replay.NextAction(r.game)
+}
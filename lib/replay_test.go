@@ -0,0 +1,63 @@
+package gorl
+
+import (
+	"bytes"
+	"encoding/gob"
+	"io/ioutil"
+	"log"
+	"testing"
+
+	"github.com/RWJMurphy/gorl/lib/roll"
+)
+
+func TestReplayRecordRoundTrip(t *testing.T) {
+	logger := log.New(ioutil.Discard, "", 0)
+	player := NewPlayer(logger, nil)
+	torch := NewItem("torch", '!', 1)
+	sword := NewWeapon("sword", ']', 5, []DamageRoll{{DamageSlashing, roll.MustParse("1d8+2")}}, 1)
+	player.AddToInventory(torch)
+	player.AddToInventory(sword)
+
+	actions := []struct {
+		action MobAction
+		state  GameState
+	}{
+		{MobAction{ActMove, MoveNorthWest}, GameWorldTurn},
+		{MobAction{ActDrop, sword}, GameWorldTurn},
+		{MobAction{ActWait, nil}, GameWorldTurn},
+		{MobAction{ActUnequip, uint(1)}, GameWorldTurn},
+		{MobAction{ActThrow, ThrowTarget{torch, Vector{3, -2}}}, GameWorldTurn},
+		{MobAction{ActNone, nil}, GameClosed},
+	}
+
+	var buf bytes.Buffer
+	encoder := gob.NewEncoder(&buf)
+	for _, a := range actions {
+		record, err := newReplayRecord(a.action, a.state, player)
+		if err != nil {
+			t.Fatalf("newReplayRecord(%s): %s", a.action, err)
+		}
+		if err = encoder.Encode(record); err != nil {
+			t.Fatalf("Encode: %s", err)
+		}
+	}
+
+	decoder := gob.NewDecoder(&buf)
+	for _, a := range actions {
+		var record replayRecord
+		if err := decoder.Decode(&record); err != nil {
+			t.Fatalf("Decode: %s", err)
+		}
+		got, err := record.mobAction(player)
+		if err != nil {
+			t.Fatalf("mobAction: %s", err)
+		}
+		if got != a.action || record.State != a.state {
+			t.Errorf("replayed %s, %s; want %s, %s", got, record.State, a.action, a.state)
+		}
+	}
+
+	if _, err := newReplayRecord(MobAction{ActDrop, NewItem("rock", '*', 1)}, GameWorldTurn, player); err == nil {
+		t.Errorf("recorded dropping an Item the Player isn't carrying")
+	}
+}
@@ -0,0 +1,36 @@
+package gorl
+
+import "math/rand"
+
+// rngSource is a rand.Source that counts how many numbers it has handed out.
+// math/rand's generators can't be saved directly, but a seed plus a count can
+// be: restoring one is a matter of reseeding and drawing that many numbers.
+type rngSource struct {
+	source rand.Source
+	seed   int64
+	draws  uint64
+}
+
+func newRNGSource(seed int64) *rngSource {
+	return &rngSource{rand.NewSource(seed), seed, 0}
+}
+
+// Int63 implements rand.Source
+func (s *rngSource) Int63() int64 {
+	s.draws++
+	return s.source.Int63()
+}
+
+// Seed implements rand.Source
+func (s *rngSource) Seed(seed int64) {
+	s.source.Seed(seed)
+	s.seed = seed
+	s.draws = 0
+}
+
+// fastForward discards numbers until draws have been made since seeding.
+func (s *rngSource) fastForward(draws uint64) {
+	for s.draws < draws {
+		s.Int63()
+	}
+}
@@ -0,0 +1,172 @@
+// Package roll parses and rolls dice expressions like "2d6+1".
+//
+// An expression is one or more terms joined by '+' or '-'. Each term is
+// either a number, or dice written NdS: N dice with S sides each. N can be
+// left out to mean 1, so "d20" is the same as "1d20".
+package roll
+
+import (
+	"fmt"
+	"math/rand"
+	"strconv"
+	"strings"
+)
+
+// term is count dice with sides sides, or just count if sides is 0, negated
+// if negative is set.
+type term struct {
+	count, sides int
+	negative     bool
+}
+
+// Dice is a parsed dice expression. The zero Dice always rolls 0.
+type Dice struct {
+	terms []term
+}
+
+// Parse parses the dice expression s.
+func Parse(s string) (Dice, error) {
+	var d Dice
+	expr := strings.Replace(s, " ", "", -1)
+	if expr == "" {
+		return d, fmt.Errorf("roll: empty dice expression")
+	}
+	negative := false
+	for len(expr) > 0 {
+		end := strings.IndexAny(expr, "+-")
+		if end == 0 {
+			return d, fmt.Errorf("roll: missing term in %q", s)
+		}
+		if end < 0 {
+			end = len(expr)
+		}
+		t, err := parseTerm(expr[:end])
+		if err != nil {
+			return d, fmt.Errorf("roll: %s in %q", err, s)
+		}
+		t.negative = negative
+		d.terms = append(d.terms, t)
+
+		if end == len(expr) {
+			break
+		}
+		negative = expr[end] == '-'
+		expr = expr[end+1:]
+		if expr == "" {
+			return d, fmt.Errorf("roll: missing term at the end of %q", s)
+		}
+	}
+	return d, nil
+}
+
+// MustParse is like Parse, but panics if s isn't a dice expression. Handy
+// for expressions written in code.
+func MustParse(s string) Dice {
+	d, err := Parse(s)
+	if err != nil {
+		panic(err)
+	}
+	return d
+}
+
+func parseTerm(s string) (term, error) {
+	var t term
+	d := strings.IndexAny(s, "dD")
+	if d < 0 {
+		n, err := strconv.Atoi(s)
+		if err != nil || n < 0 {
+			return t, fmt.Errorf("bad number %q", s)
+		}
+		t.count = n
+		return t, nil
+	}
+
+	t.count = 1
+	if d > 0 {
+		n, err := strconv.Atoi(s[:d])
+		if err != nil || n < 1 {
+			return t, fmt.Errorf("bad number of dice %q", s[:d])
+		}
+		t.count = n
+	}
+	n, err := strconv.Atoi(s[d+1:])
+	if err != nil || n < 1 {
+		return t, fmt.Errorf("bad number of sides %q", s[d+1:])
+	}
+	t.sides = n
+	return t, nil
+}
+
+// Roll rolls the dice.
+func (d Dice) Roll(r *rand.Rand) int {
+	total := 0
+	for _, t := range d.terms {
+		n := t.count
+		if t.sides > 0 {
+			n = 0
+			for i := 0; i < t.count; i++ {
+				n += r.Intn(t.sides) + 1
+			}
+		}
+		if t.negative {
+			n = -n
+		}
+		total += n
+	}
+	return total
+}
+
+// Min returns the lowest total the dice can roll.
+func (d Dice) Min() int {
+	total := 0
+	for _, t := range d.terms {
+		switch {
+		case t.negative && t.sides > 0:
+			total -= t.count * t.sides
+		case t.negative:
+			total -= t.count
+		default:
+			total += t.count
+		}
+	}
+	return total
+}
+
+// Max returns the highest total the dice can roll.
+func (d Dice) Max() int {
+	total := 0
+	for _, t := range d.terms {
+		n := t.count
+		if t.sides > 0 {
+			n *= t.sides
+		}
+		if t.negative {
+			total -= t.count
+		} else {
+			total += n
+		}
+	}
+	return total
+}
+
+// String returns the dice expression, such that Parse(d.String()) is d.
+func (d Dice) String() string {
+	if len(d.terms) == 0 {
+		return "0"
+	}
+	var b strings.Builder
+	for i, t := range d.terms {
+		switch {
+		case t.negative:
+			b.WriteByte('-')
+		case i > 0:
+			b.WriteByte('+')
+		}
+		if t.sides > 0 {
+			fmt.Fprintf(&b, "%dd%d", t.count, t.sides)
+		} else {
+			fmt.Fprintf(&b, "%d", t.count)
+		}
+	}
+	return b.String()
+}
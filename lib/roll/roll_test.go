@@ -0,0 +1,61 @@
+package roll
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		in       string
+		want     string
+		min, max int
+	}{
+		{"5", "5", 5, 5},
+		{"d6", "1d6", 1, 6},
+		{"2d6+1", "2d6+1", 3, 13},
+		{"1d8 + 1d4 - 2", "1d8+1d4-2", 0, 10},
+		{"3D4", "3d4", 3, 12},
+		{"10-1d4", "10-1d4", 6, 9},
+	}
+	for _, test := range tests {
+		d, err := Parse(test.in)
+		if err != nil {
+			t.Errorf("Parse(%q) returned error %s", test.in, err)
+			continue
+		}
+		if got := d.String(); got != test.want {
+			t.Errorf("Parse(%q).String() = %q, want %q", test.in, got, test.want)
+		}
+		if d.Min() != test.min || d.Max() != test.max {
+			t.Errorf("Parse(%q) rolls %d to %d, want %d to %d", test.in, d.Min(), d.Max(), test.min, test.max)
+		}
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	for _, in := range []string{"", "d", "2d", "0d6", "2d0", "+1", "1d6+", "1d6++1", "x", "1dx", "-3"} {
+		if d, err := Parse(in); err == nil {
+			t.Errorf("Parse(%q) = %s, want an error", in, d)
+		}
+	}
+}
+
+func TestRoll(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	d := MustParse("2d6+1")
+	seen := make(map[int]bool)
+	for i := 0; i < 1000; i++ {
+		n := d.Roll(r)
+		if n < d.Min() || n > d.Max() {
+			t.Fatalf("%s rolled %d", d, n)
+		}
+		seen[n] = true
+	}
+	if len(seen) != 11 {
+		t.Errorf("%s rolled %d different totals in 1000 rolls, want all 11", d, len(seen))
+	}
+	if n := (Dice{}).Roll(r); n != 0 {
+		t.Errorf("zero Dice rolled %d", n)
+	}
+}
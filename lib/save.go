@@ -0,0 +1,390 @@
+package gorl
+
+import (
+	"encoding/gob"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"reflect"
+
+	"github.com/nsf/termbox-go"
+)
+
+// SaveVersion is the version of the save format written by Game.Save. Saves
+// from any other version are refused.
+const SaveVersion = 12
+
+// A FeatureSaver turns a Feature into a gob-encodable record.
+type FeatureSaver func(Feature, *Saver) (interface{}, error)
+
+// A FeatureLoader rebuilds a Feature from a record made by a FeatureSaver.
+type FeatureLoader func(interface{}, *Loader) (Feature, error)
+
+type featureCodec struct {
+	name string
+	save FeatureSaver
+	load FeatureLoader
+}
+
+var (
+	featureCodecsByName = make(map[string]*featureCodec)
+	featureCodecsByType = make(map[reflect.Type]*featureCodec)
+)
+
+// RegisterFeatureType makes a Feature type saveable. example is any value of
+// the concrete type; record is an example of what save returns, and is
+// registered with gob under name.
+func RegisterFeatureType(name string, example Feature, record interface{}, save FeatureSaver, load FeatureLoader) {
+	if _, exists := featureCodecsByName[name]; exists {
+		panic(fmt.Sprintf("Feature type %s registered twice", name))
+	}
+	codec := &featureCodec{name, save, load}
+	featureCodecsByName[name] = codec
+	featureCodecsByType[reflect.TypeOf(example)] = codec
+	gob.RegisterName("gorl."+name, record)
+}
+
+// savedFeature is any Feature, tagged with the name of its codec.
+type savedFeature struct {
+	Type   string
+	Record interface{}
+}
+
+// Saver turns Features into savedFeatures.
+type Saver struct{}
+
+// SaveFeature saves any registered Feature. A nil Feature is saved as an
+// empty record.
+func (s *Saver) SaveFeature(f Feature) (savedFeature, error) {
+	if f == nil {
+		return savedFeature{}, nil
+	}
+	codec, ok := featureCodecsByType[reflect.TypeOf(f)]
+	if !ok {
+		return savedFeature{}, fmt.Errorf("Don't know how to save %T", f)
+	}
+	record, err := codec.save(f, s)
+	if err != nil {
+		return savedFeature{}, err
+	}
+	return savedFeature{codec.name, record}, nil
+}
+
+// SaveItems saves a slice of Items.
+func (s *Saver) SaveItems(items []Item) ([]savedFeature, error) {
+	saved := make([]savedFeature, len(items))
+	for i, item := range items {
+		var err error
+		if saved[i], err = s.SaveFeature(item); err != nil {
+			return nil, err
+		}
+	}
+	return saved, nil
+}
+
+// Loader turns savedFeatures back into Features.
+type Loader struct {
+	log *log.Logger
+}
+
+// LoadFeature rebuilds any registered Feature. An empty record loads as nil.
+func (l *Loader) LoadFeature(saved savedFeature) (Feature, error) {
+	if saved.Type == "" {
+		return nil, nil
+	}
+	codec, ok := featureCodecsByName[saved.Type]
+	if !ok {
+		return nil, fmt.Errorf("Don't know how to load %s", saved.Type)
+	}
+	return codec.load(saved.Record, l)
+}
+
+// LoadItems rebuilds a slice of Items.
+func (l *Loader) LoadItems(saved []savedFeature) ([]Item, error) {
+	items := make([]Item, 0, len(saved))
+	for _, s := range saved {
+		f, err := l.LoadFeature(s)
+		if err != nil {
+			return nil, err
+		}
+		item, ok := f.(Item)
+		if !ok {
+			return nil, fmt.Errorf("%s is not an Item", f)
+		}
+		items = append(items, item)
+	}
+	return items, nil
+}
+
+type vectorRecord struct {
+	X, Y int
+}
+
+func newVectorRecord(v Vector) vectorRecord {
+	return vectorRecord{v.x, v.y}
+}
+
+func (r vectorRecord) vector() Vector {
+	return Vector{r.X, r.Y}
+}
+
+type tileRecord struct {
+	C     rune
+	Color termbox.Attribute
+	Flags Flag
+}
+
+type featureGroupRecord struct {
+	Loc     vectorRecord
+	Feature savedFeature
+	Items   []savedFeature
+}
+
+type scheduledMobRecord struct {
+	Mob    savedFeature
+	Time   uint
+	Order  uint
+	Player bool
+}
+
+type dungeonRecord struct {
+	Width, Height int
+	Depth         int
+	Origin        vectorRecord
+	UpStairs      vectorRecord
+	DownStairs    vectorRecord
+	Tiles         []tileRecord
+	Features      []featureGroupRecord
+	Mobs          []scheduledMobRecord
+	Memories      []memoryRecord
+	Now           uint
+	Counter       uint
+}
+
+type gameRecord struct {
+	Version  int
+	Seed     int64
+	Draws    uint64
+	Width    int
+	Height   int
+	Turn     uint
+	Messages []string
+	Depth    int
+	Dungeons []dungeonRecord
+	Levels   Levels
+}
+
+func (game *Game) saveDungeon(d *Dungeon, s *Saver) (dungeonRecord, error) {
+	record := dungeonRecord{
+		Width:      d.width,
+		Height:     d.height,
+		Depth:      d.depth,
+		Origin:     newVectorRecord(d.origin),
+		UpStairs:   newVectorRecord(d.upStairs),
+		DownStairs: newVectorRecord(d.downStairs),
+		Tiles:      make([]tileRecord, 0, d.width*d.height),
+		Memories:   d.memoryRecords(),
+		Now:        d.scheduler.now,
+		Counter:    d.scheduler.counter,
+	}
+	for y := 0; y < d.height; y++ {
+		for x := 0; x < d.width; x++ {
+			t := d.tiles[y][x]
+			record.Tiles = append(record.Tiles, tileRecord{t.c, t.color, t.flags})
+		}
+	}
+
+	for _, loc := range d.featureLocs() {
+		fg := d.features[loc]
+		if fg.feature == nil && len(fg.items) == 0 {
+			continue
+		}
+		feature, err := s.SaveFeature(fg.feature)
+		if err != nil {
+			return record, err
+		}
+		items, err := s.SaveItems(fg.items)
+		if err != nil {
+			return record, err
+		}
+		record.Features = append(record.Features, featureGroupRecord{newVectorRecord(loc), feature, items})
+	}
+
+	for _, entry := range d.scheduler.inOrder() {
+		mob, err := s.SaveFeature(entry.mob)
+		if err != nil {
+			return record, err
+		}
+		record.Mobs = append(record.Mobs, scheduledMobRecord{
+			mob,
+			entry.time,
+			entry.order,
+			entry.mob == Mob(game.player),
+		})
+	}
+	return record, nil
+}
+
+func (game *Game) loadDungeon(record dungeonRecord, l *Loader) (*Dungeon, error) {
+	if len(record.Tiles) != record.Width*record.Height {
+		return nil, fmt.Errorf("Dungeon at depth %d has %d tiles, want %d", record.Depth, len(record.Tiles), record.Width*record.Height)
+	}
+	d := NewDungeon(record.Width, record.Height, game.log)
+	d.depth = record.Depth
+	d.origin = record.Origin.vector()
+	d.upStairs = record.UpStairs.vector()
+	d.downStairs = record.DownStairs.vector()
+	for i, t := range record.Tiles {
+		d.tiles[i/d.width][i%d.width] = NewTile(t.C, t.Color, t.Flags)
+	}
+	for _, m := range record.Memories {
+		d.memories[m.Loc.vector()] = m.Memory
+	}
+
+	for _, fgRecord := range record.Features {
+		feature, err := l.LoadFeature(fgRecord.Feature)
+		if err != nil {
+			return nil, err
+		}
+		if feature != nil {
+			d.AddFeature(feature)
+		}
+		items, err := l.LoadItems(fgRecord.Items)
+		if err != nil {
+			return nil, err
+		}
+		for _, item := range items {
+			d.AddItem(item)
+		}
+	}
+
+	for _, mobRecord := range record.Mobs {
+		f, err := l.LoadFeature(mobRecord.Mob)
+		if err != nil {
+			return nil, err
+		}
+		mob, ok := f.(Mob)
+		if !ok {
+			return nil, fmt.Errorf("%s is not a Mob", f)
+		}
+		d.AddMob(mob)
+		d.scheduler.restore(mob, mobRecord.Time, mobRecord.Order)
+		if mobRecord.Player {
+			player, ok := mob.(Player)
+			if !ok {
+				return nil, fmt.Errorf("%s is not a Player", mob)
+			}
+			game.player = player
+		}
+	}
+	d.scheduler.now = record.Now
+	d.scheduler.counter = record.Counter
+	return d, nil
+}
+
+// Save writes the Game to w.
+func (game *Game) Save(w io.Writer) error {
+	s := &Saver{}
+	record := gameRecord{
+		Version:  SaveVersion,
+		Seed:     game.rng.seed,
+		Draws:    game.rng.draws,
+		Width:    game.width,
+		Height:   game.height,
+		Turn:     game.turn,
+		Messages: game.messages,
+		Depth:    game.currentDungeon.Depth(),
+		Levels:   game.config.Levels,
+	}
+	for _, d := range game.dungeons {
+		dungeonRecord, err := game.saveDungeon(d, s)
+		if err != nil {
+			return err
+		}
+		record.Dungeons = append(record.Dungeons, dungeonRecord)
+	}
+	return gob.NewEncoder(w).Encode(record)
+}
+
+// SaveFile writes the Game to the file at path, replacing it only once the
+// whole Game has been written.
+func (game *Game) SaveFile(path string) error {
+	tmpPath := path + ".tmp"
+	f, err := os.Create(tmpPath)
+	if err != nil {
+		return err
+	}
+	if err = game.Save(f); err != nil {
+		f.Close()
+		os.Remove(tmpPath)
+		return err
+	}
+	if err = f.Close(); err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+	return os.Rename(tmpPath, path)
+}
+
+// LoadGame reads a Game saved by Game.Save from r, and starts it up like
+// NewGame does. The seed, level size and, if they were set up by Levels, how
+// to make levels nobody has visited yet come from the save; everything else
+// comes from config. Please `defer game.Close()`.
+func LoadGame(log *log.Logger, r io.Reader, config GameConfig) (*Game, error) {
+	var record gameRecord
+	if err := gob.NewDecoder(r).Decode(&record); err != nil {
+		return nil, err
+	}
+	if record.Version != SaveVersion {
+		return nil, fmt.Errorf("Save is version %d, can only load version %d", record.Version, SaveVersion)
+	}
+
+	config.Seed = record.Seed
+	config.Width = record.Width
+	config.Height = record.Height
+	if record.Levels.Generators != "" {
+		if err := record.Levels.Configure(&config); err != nil {
+			return nil, err
+		}
+	}
+	if err := config.validate(); err != nil {
+		return nil, err
+	}
+	game := newGame(log, config)
+	game.rng.fastForward(record.Draws)
+	game.turn = record.Turn
+	game.messages = record.Messages
+
+	l := &Loader{log}
+	for _, dungeonRecord := range record.Dungeons {
+		d, err := game.loadDungeon(dungeonRecord, l)
+		if err != nil {
+			return nil, err
+		}
+		game.dungeons = append(game.dungeons, d)
+	}
+	if record.Depth < 0 || record.Depth >= len(game.dungeons) {
+		return nil, fmt.Errorf("Save is on depth %d, but only has %d levels", record.Depth, len(game.dungeons))
+	}
+	if game.player == nil {
+		return nil, errors.New("Save has no Player")
+	}
+
+	if err := game.start(game.dungeons[record.Depth]); err != nil {
+		return nil, err
+	}
+	game.AddMessage("Welcome back to GoRL!")
+	return game, nil
+}
+
+// LoadGameFile reads a Game from the file at path.
+func LoadGameFile(log *log.Logger, path string, config GameConfig) (*Game, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return LoadGame(log, f, config)
+}
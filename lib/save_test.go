@@ -0,0 +1,143 @@
+package gorl
+
+import (
+	"bytes"
+	"encoding/gob"
+	"io/ioutil"
+	"log"
+	"testing"
+
+	"github.com/RWJMurphy/gorl/lib/roll"
+)
+
+func TestSaveDungeonRoundTrip(t *testing.T) {
+	logger := log.New(ioutil.Discard, "", 0)
+	game := newGame(logger, DefaultGameConfig(1, testContent(t)))
+	d := game.dungeonAt(0)
+	game.player = NewPlayer(logger, d)
+	loc, ok := d.RandomFreeLoc(game.dice)
+	if !ok {
+		t.Fatal("Nowhere free to put the Player")
+	}
+	game.player.SetLoc(loc)
+	sword := NewWeapon("sword", ']', 5, []DamageRoll{{DamageSlashing, roll.MustParse("1d8+2")}}, 1)
+	game.player.AddToInventory(sword)
+	game.player.Wield(sword, 0)
+	game.player.AddToInventory(NewItem("torch", '!', 1))
+	d.AddMob(game.player)
+	d.Remember(d.downStairs)
+
+	var buf bytes.Buffer
+	record, err := game.saveDungeon(d, &Saver{})
+	if err != nil {
+		t.Fatalf("saveDungeon: %s", err)
+	}
+	if err = gob.NewEncoder(&buf).Encode(record); err != nil {
+		t.Fatalf("Encode: %s", err)
+	}
+	var decoded dungeonRecord
+	if err = gob.NewDecoder(&buf).Decode(&decoded); err != nil {
+		t.Fatalf("Decode: %s", err)
+	}
+
+	original := game.player
+	game.player = nil
+	loaded, err := game.loadDungeon(decoded, &Loader{logger})
+	if err != nil {
+		t.Fatalf("loadDungeon: %s", err)
+	}
+
+	if game.player == nil {
+		t.Fatalf("loadDungeon didn't find the Player")
+	}
+	if game.player.Loc() != original.Loc() {
+		t.Errorf("Player loaded at %s, want %s", game.player.Loc(), original.Loc())
+	}
+	if got := len(game.player.Inventory()); got != 1 {
+		t.Errorf("Player loaded with %d items, want 1", got)
+	}
+	if w := game.player.Wielding()[0]; w == nil || w.Name() != "sword" {
+		t.Errorf("Player loaded wielding %v, want sword", w)
+	}
+	if loaded.MobAt(game.player.Loc()) != game.player {
+		t.Errorf("Player not at their location in the loaded Dungeon")
+	}
+
+	if len(loaded.Mobs()) != len(d.Mobs()) {
+		t.Errorf("loaded %d Mobs, want %d", len(loaded.Mobs()), len(d.Mobs()))
+	}
+	for i, entry := range loaded.scheduler.inOrder() {
+		want := d.scheduler.inOrder()[i]
+		if entry.mob.Name() != want.mob.Name() || entry.time != want.time {
+			t.Errorf("scheduled Mob %d = %s@%d, want %s@%d", i, entry.mob.Name(), entry.time, want.mob.Name(), want.time)
+		}
+	}
+	for y := 0; y < d.height; y++ {
+		for x := 0; x < d.width; x++ {
+			if loaded.tiles[y][x] != d.tiles[y][x] {
+				t.Fatalf("tile at (%d, %d) = %s, want %s", x, y, loaded.tiles[y][x], d.tiles[y][x])
+			}
+		}
+	}
+	stairs, ok := loaded.FeatureAt(d.downStairs).(Stairs)
+	if !ok || !stairs.Down() {
+		t.Errorf("FeatureAt(downStairs) = %v, want Stairs down", loaded.FeatureAt(d.downStairs))
+	}
+	if memory, ok := loaded.Memory(d.downStairs); !ok || memory.Char != '>' {
+		t.Errorf("Memory(downStairs) = %v, %v; want the stairs", memory, ok)
+	}
+}
+
+func TestRNGSourceFastForward(t *testing.T) {
+	a := newRNGSource(42)
+	for i := 0; i < 10; i++ {
+		a.Int63()
+	}
+	b := newRNGSource(42)
+	b.fastForward(a.draws)
+	if a.Int63() != b.Int63() {
+		t.Errorf("fast forwarded rngSource out of step with the original")
+	}
+}
+
+func TestSaveKeepsLevels(t *testing.T) {
+	logger := log.New(ioutil.Discard, "", 0)
+	content := testContent(t)
+	config := DefaultGameConfig(1, content)
+	config.Width, config.Height = 40, 30
+	config.NewUI = NewHeadlessUI(20, 10, NewScriptedActions())
+	levels := Levels{Generators: "bsp,caves", MapPath: "test.txt", Map: []byte(testLevelMap)}
+	if err := levels.Configure(&config); err != nil {
+		t.Fatal(err)
+	}
+	game, err := NewGame(logger, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer game.Close()
+	var buf bytes.Buffer
+	if err := game.Save(&buf); err != nil {
+		t.Fatal(err)
+	}
+
+	// Loaded with the default generator, the save still makes new levels
+	// the way it was started
+	defaults := DefaultGameConfig(2, content)
+	defaults.NewUI = config.NewUI
+	loaded, err := LoadGame(logger, &buf, defaults)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer loaded.Close()
+	if got := loaded.config.Levels; got.Generators != levels.Generators || got.MapPath != levels.MapPath || string(got.Map) != testLevelMap {
+		t.Errorf("Loaded levels made by %q on map %q, want %q on %q", got.Generators, got.MapPath, levels.Generators, levels.MapPath)
+	}
+	want, got := game.dungeonAt(1), loaded.dungeonAt(1)
+	for y := 0; y < want.height; y++ {
+		for x := 0; x < want.width; x++ {
+			if got.tiles[y][x] != want.tiles[y][x] {
+				t.Fatalf("Loaded game made a different second level: tile at (%d, %d) = %s, want %s", x, y, got.tiles[y][x], want.tiles[y][x])
+			}
+		}
+	}
+}
@@ -0,0 +1,203 @@
+package gorl
+
+import (
+	"container/heap"
+	"fmt"
+	"sort"
+)
+
+// scheduleEntry is a Mob waiting in a scheduler for its next turn.
+type scheduleEntry struct {
+	mob   Mob
+	time  uint
+	order uint
+	index int
+}
+
+// scheduleQueue is a min-heap of scheduleEntries, ordered by the time they're
+// next due to act. Ties are broken by the order they were (re)scheduled in, so
+// the same sequence of events always produces the same turn order.
+type scheduleQueue []*scheduleEntry
+
+func (q scheduleQueue) Len() int {
+	return len(q)
+}
+
+func (q scheduleQueue) Less(i, j int) bool {
+	if q[i].time == q[j].time {
+		return q[i].order < q[j].order
+	}
+	return q[i].time < q[j].time
+}
+
+func (q scheduleQueue) Swap(i, j int) {
+	q[i], q[j] = q[j], q[i]
+	q[i].index = i
+	q[j].index = j
+}
+
+func (q *scheduleQueue) Push(x interface{}) {
+	entry := x.(*scheduleEntry)
+	entry.index = len(*q)
+	*q = append(*q, entry)
+}
+
+func (q *scheduleQueue) Pop() interface{} {
+	old := *q
+	n := len(old)
+	entry := old[n-1]
+	old[n-1] = nil
+	entry.index = -1
+	*q = old[:n-1]
+	return entry
+}
+
+// A scheduler decides which Mob acts next. Every Mob has a time at which it is
+// next due to act; acting pushes that time back by the energy the action
+// cost, scaled by the Mob's speed.
+type scheduler struct {
+	queue   scheduleQueue
+	entries map[Mob]*scheduleEntry
+	now     uint
+	counter uint
+}
+
+func newScheduler() *scheduler {
+	return &scheduler{
+		make(scheduleQueue, 0),
+		make(map[Mob]*scheduleEntry),
+		0,
+		0,
+	}
+}
+
+func (s *scheduler) String() string {
+	return fmt.Sprintf("<scheduler now:%d, mobs:%d>", s.now, len(s.queue))
+}
+
+// Now returns the time of the most recently started turn.
+func (s *scheduler) Now() uint {
+	return s.now
+}
+
+// Add schedules mob to act at the current time, after any Mobs already due.
+// Adding an already scheduled Mob does nothing.
+func (s *scheduler) Add(mob Mob) {
+	if _, exists := s.entries[mob]; exists {
+		return
+	}
+	entry := &scheduleEntry{mob, s.now, s.nextOrder(), -1}
+	s.entries[mob] = entry
+	heap.Push(&s.queue, entry)
+}
+
+// Remove unschedules mob.
+func (s *scheduler) Remove(mob Mob) {
+	entry, exists := s.entries[mob]
+	if !exists {
+		return
+	}
+	heap.Remove(&s.queue, entry.index)
+	delete(s.entries, mob)
+}
+
+// Scheduled returns true if mob is in the scheduler.
+func (s *scheduler) Scheduled(mob Mob) bool {
+	_, exists := s.entries[mob]
+	return exists
+}
+
+// Peek returns the Mob due to act next, or nil if nothing is scheduled.
+func (s *scheduler) Peek() Mob {
+	if len(s.queue) == 0 {
+		return nil
+	}
+	return s.queue[0].mob
+}
+
+// Next advances the clock to the next Mob's turn, and returns it. The Mob
+// stays at the head of the queue until it Spends some energy.
+func (s *scheduler) Next() Mob {
+	if len(s.queue) == 0 {
+		return nil
+	}
+	entry := s.queue[0]
+	if entry.time > s.now {
+		s.now = entry.time
+	}
+	return entry.mob
+}
+
+// Spend reschedules mob after it has used energy worth of action.
+func (s *scheduler) Spend(mob Mob, energy uint) {
+	entry, exists := s.entries[mob]
+	if !exists {
+		return
+	}
+	entry.time += turnDelay(energy, mob.Speed())
+	entry.order = s.nextOrder()
+	heap.Fix(&s.queue, entry.index)
+}
+
+// Sync moves the clock to now, shifting every Mob's next turn by the same
+// amount. Dungeons the Player isn't on don't tick, so this brings one up to
+// date when it's entered again.
+func (s *scheduler) Sync(now uint) {
+	for _, entry := range s.queue {
+		entry.time = entry.time - s.now + now
+	}
+	s.now = now
+}
+
+// inOrder returns every scheduled entry, in the order they're due to act.
+func (s *scheduler) inOrder() []*scheduleEntry {
+	entries := make(scheduleOrder, len(s.queue))
+	copy(entries, s.queue)
+	sort.Sort(entries)
+	return entries
+}
+
+// scheduleOrder sorts scheduleEntries without disturbing their place in the
+// scheduleQueue heap.
+type scheduleOrder []*scheduleEntry
+
+func (o scheduleOrder) Len() int {
+	return len(o)
+}
+
+func (o scheduleOrder) Less(i, j int) bool {
+	return scheduleQueue(o).Less(i, j)
+}
+
+func (o scheduleOrder) Swap(i, j int) {
+	o[i], o[j] = o[j], o[i]
+}
+
+// restore puts mob back at exactly the time and order it was saved with.
+func (s *scheduler) restore(mob Mob, time, order uint) {
+	entry, exists := s.entries[mob]
+	if !exists {
+		return
+	}
+	entry.time = time
+	entry.order = order
+	heap.Fix(&s.queue, entry.index)
+}
+
+func (s *scheduler) nextOrder() uint {
+	s.counter++
+	return s.counter
+}
+
+// turnDelay converts an amount of energy into time, for a Mob of the given
+// speed. A Mob with MobDefaultSpeed takes exactly energy time.
+func turnDelay(energy uint, speed uint) uint {
+	if speed == 0 {
+		speed = 1
+	}
+	delay := energy * MobDefaultSpeed / speed
+	if delay == 0 {
+		delay = 1
+	}
+	return delay
+}
@@ -0,0 +1,68 @@
+package gorl
+
+import (
+	"io/ioutil"
+	"log"
+	"testing"
+)
+
+func TestSchedulerOrder(t *testing.T) {
+	logger := log.New(ioutil.Discard, "", 0)
+	slow := NewMob("slow", 's', logger, nil)
+	normal := NewMob("normal", 'n', logger, nil)
+	fast := NewMob("fast", 'f', logger, nil)
+	slow.SetSpeed(MobDefaultSpeed / 2)
+	fast.SetSpeed(MobDefaultSpeed * 2)
+
+	s := newScheduler()
+	s.Add(slow)
+	s.Add(normal)
+	s.Add(fast)
+
+	want := []Mob{
+		slow, normal, fast, // everyone starts at time 0, in the order added
+		fast,   // 50
+		normal, // 100
+		fast,   // 100
+		fast,   // 150
+		slow,   // 200
+		normal, // 200
+		fast,   // 200
+	}
+	for i, w := range want {
+		got := s.Next()
+		if got != w {
+			t.Fatalf("turn %d: Next() = %s, want %s", i, got.Name(), w.Name())
+		}
+		s.Spend(got, EnergyPerTurn)
+	}
+	if s.Now() != 200 {
+		t.Errorf("Now() = %d, want 200", s.Now())
+	}
+}
+
+func TestSchedulerRemove(t *testing.T) {
+	logger := log.New(ioutil.Discard, "", 0)
+	a := NewMob("a", 'a', logger, nil)
+	b := NewMob("b", 'b', logger, nil)
+
+	s := newScheduler()
+	s.Add(a)
+	s.Add(b)
+	s.Add(a)
+	if got := len(s.queue); got != 2 {
+		t.Errorf("adding a Mob twice: queue length = %d, want 2", got)
+	}
+
+	s.Remove(a)
+	if s.Scheduled(a) {
+		t.Errorf("Scheduled(a) = true after Remove(a)")
+	}
+	if got := s.Next(); got != b {
+		t.Errorf("Next() = %s, want b", got)
+	}
+	s.Remove(b)
+	if got := s.Next(); got != nil {
+		t.Errorf("Next() on empty scheduler = %s, want nil", got)
+	}
+}
@@ -0,0 +1,52 @@
+package gorl
+
+// Stairs link a Dungeon to the levels above and below it
+type Stairs interface {
+	Feature
+	Down() bool
+}
+
+func init() {
+	RegisterFeatureType("stairs", &stairs{}, stairsRecord{},
+		func(f Feature, s *Saver) (interface{}, error) {
+			st := f.(*stairs)
+			return stairsRecord{st.feature.record(), st.down}, nil
+		},
+		func(record interface{}, l *Loader) (Feature, error) {
+			r := record.(stairsRecord)
+			st := &stairs{}
+			st.feature.restore(r.Feature)
+			st.down = r.Down
+			return st, nil
+		},
+	)
+}
+
+type stairs struct {
+	feature
+	down bool
+}
+
+// NewStairs returns a new staircase, leading down if down is true and up
+// otherwise.
+func NewStairs(down bool) Stairs {
+	var s *stairs
+	if down {
+		s = &stairs{*NewFeature("staircase down", '>').(*feature), down}
+	} else {
+		s = &stairs{*NewFeature("staircase up", '<').(*feature), down}
+	}
+	s.flags |= FlagCrossable
+	return s
+}
+
+// Down returns true if the Stairs lead down, and false if they lead up
+func (s *stairs) Down() bool {
+	return s.down
+}
+
+// stairsRecord is the saved form of some stairs
+type stairsRecord struct {
+	Feature featureRecord
+	Down    bool
+}
@@ -3,6 +3,8 @@ package gorl
 import (
 	"fmt"
 	"log"
+	"os"
+	"strings"
 
 	"github.com/imdario/mergo"
 	"github.com/nsf/termbox-go"
@@ -22,8 +24,10 @@ type termboxUI struct {
 	paintables      []Paintable
 	cameraWidget    *cameraWidget
 	menuWidget      *menuWidget
+	lookWidget      *lookWidget
 	logWidget       *logWidget
 	inventoryWidget *inventoryWidget
+	equipmentWidget *equipmentWidget
 	messages        []string
 	state           State
 	game            *Game
@@ -31,6 +35,16 @@ type termboxUI struct {
 	log             *log.Logger
 	// ugh this is hacky
 	stateAction MobAction
+	// targets are the Mobs that can be cycled through while targeting, and
+	// targetRange is how far the projectile can go
+	targets     []Mob
+	targetIndex int
+	targetRange int
+}
+
+// TermboxUIFactory makes a TermboxUI for a Game
+var TermboxUIFactory UIFactory = func(game *Game) (UI, error) {
+	return NewTermboxUI(game)
 }
 
 func NewTermboxUI(game *Game) (TermboxUI, error) {
@@ -47,23 +61,47 @@ func NewTermboxUI(game *Game) (TermboxUI, error) {
 		widget{Rectangle{}, ui},
 		nil,
 	}
+	// termbox switches to 256 colors whether the terminal has them or not
+	colors256 := has256Colors(os.Getenv)
+	if colors256 {
+		termbox.SetOutputMode(termbox.Output256)
+	}
 	ui.cameraWidget = &cameraWidget{
-		widget{Rectangle{}, ui},
-		nil,
-		Vector{0, 0},
+		widget:    widget{Rectangle{}, ui},
+		colors256: colors256,
 	}
 	ui.menuWidget = &menuWidget{
 		widget{Rectangle{}, ui},
+		game,
+	}
+	ui.lookWidget = &lookWidget{
+		widget{Rectangle{}, ui},
+		ui.cameraWidget,
 	}
 	ui.inventoryWidget = &inventoryWidget{
 		widget{Rectangle{}, ui},
 		game.player,
 	}
+	ui.equipmentWidget = &equipmentWidget{
+		widget{Rectangle{}, ui},
+		game.player,
+	}
 	ui.Resize()
 	ui.setState(StateGame, MobAction{ActNone, nil})
 	return ui, nil
 }
 
+// has256Colors returns true if the terminal described by the environment
+// variables getenv looks up says it can show 256 colors or more.
+func has256Colors(getenv func(string) string) bool {
+	switch getenv("COLORTERM") {
+	case "truecolor", "24bit":
+		return true
+	}
+	term := getenv("TERM")
+	return strings.Contains(term, "256color") || strings.HasSuffix(term, "-direct")
+}
+
 func (ui *termboxUI) Resize() {
 	width, height := termbox.Size()
 
@@ -73,16 +111,24 @@ func (ui *termboxUI) Resize() {
 	ui.menuWidget.topLeft = Vector{width - width/4, 0}
 	ui.menuWidget.size = Vector{width / 4, height - height/4}
 
+	ui.lookWidget.topLeft = ui.menuWidget.topLeft
+	ui.lookWidget.size = ui.menuWidget.size
+
 	ui.logWidget.topLeft = Vector{0, height - height/4}
 	ui.logWidget.size = Vector{width, height / 4}
 
 	ui.inventoryWidget.topLeft = Vector{0, 0}
 	ui.inventoryWidget.size = Vector{width, height - height/4}
 
+	ui.equipmentWidget.topLeft = Vector{0, 0}
+	ui.equipmentWidget.size = Vector{width, height - height/4}
+
 	ui.log.Println(ui.cameraWidget)
 	ui.log.Println(ui.menuWidget)
+	ui.log.Println(ui.lookWidget)
 	ui.log.Println(ui.logWidget)
 	ui.log.Println(ui.inventoryWidget)
+	ui.log.Println(ui.equipmentWidget)
 
 	ui.MarkDirty()
 }
@@ -114,7 +160,7 @@ func (ui *termboxUI) DoEvent() (MobAction, GameState) {
 	nextState := ui.game.state
 
 	switch ui.State() {
-	case StateGame, StateInventory:
+	case StateGame, StateInventory, StateEquipment, StateTargeting, StateLook, StateDirection:
 		event := termbox.PollEvent()
 		action, nextState = ui.HandleEvent(event)
 	case StateClosed:
@@ -164,7 +210,7 @@ func (ui *termboxUI) HandleKey(char rune, key termbox.Key) (MobAction, GameState
 	case StateGame:
 		switch char {
 		// Quit
-		case 'q':
+		case 'Q':
 			return MobAction{ActNone, nil}, GameClosed
 		// Move
 		case 'h', 'j', 'k', 'l', 'y', 'u', 'b', 'n':
@@ -180,11 +226,64 @@ func (ui *termboxUI) HandleKey(char rune, key termbox.Key) (MobAction, GameState
 		case 'd':
 			ui.setState(StateInventory, MobAction{ActDrop, nil})
 			return MobAction{ActNone, nil}, GamePlayerTurn
+		// Wear
+		case 'W':
+			ui.setState(StateInventory, MobAction{ActEquip, nil})
+			return MobAction{ActNone, nil}, GamePlayerTurn
+		// Open, close
+		case 'o':
+			ui.game.AddMessage("Open in which direction?")
+			ui.setState(StateDirection, MobAction{ActOpen, nil})
+			return MobAction{ActNone, nil}, GamePlayerTurn
+		case 'c':
+			ui.game.AddMessage("Close in which direction?")
+			ui.setState(StateDirection, MobAction{ActClose, nil})
+			return MobAction{ActNone, nil}, GamePlayerTurn
+		// Look
+		case 'x', ';':
+			ui.setState(StateLook, MobAction{ActNone, nil})
+			ui.setCursor(ui.game.player.Loc())
+			return MobAction{ActNone, nil}, ui.game.state
+		// Fire
+		case 'f':
+			weapon, ok := RangedWeapon(ui.game.player)
+			if !ok {
+				ui.game.AddMessage("You have nothing to fire")
+				return MobAction{ActNone, nil}, ui.game.state
+			}
+			ui.startTargeting(MobAction{ActFire, nil}, weapon.Range())
+			return MobAction{ActNone, nil}, GamePlayerTurn
+		// Throw
+		case 't':
+			ui.setState(StateInventory, MobAction{ActThrow, nil})
+			return MobAction{ActNone, nil}, GamePlayerTurn
+		// Quaff, apply
+		case 'q', 'a':
+			ui.setState(StateInventory, MobAction{ActUse, nil})
+			return MobAction{ActNone, nil}, GamePlayerTurn
+		// Take off
+		case 'T':
+			ui.setState(StateEquipment, MobAction{ActUnequip, nil})
+			return MobAction{ActNone, nil}, GamePlayerTurn
 		// Drop all
 		case 'D':
 			return MobAction{ActDropAll, nil}, GameWorldTurn
 		case ',', 'g':
 			return MobAction{ActPickUpAll, nil}, GameWorldTurn
+		// Explore
+		case 'X':
+			return MobAction{ActExplore, nil}, GameWorldTurn
+		// Travel
+		case '_':
+			ui.game.AddMessage("Travel where?")
+			ui.setState(StateLook, MobAction{ActNone, nil})
+			ui.setCursor(ui.game.player.Loc())
+			return MobAction{ActNone, nil}, ui.game.state
+		// Stairs
+		case '>':
+			return MobAction{ActDescend, nil}, GameWorldTurn
+		case '<':
+			return MobAction{ActAscend, nil}, GameWorldTurn
 		case 0:
 			switch key {
 			// Quit
@@ -208,6 +307,10 @@ func (ui *termboxUI) HandleKey(char rune, key termbox.Key) (MobAction, GameState
 			inventory := ui.game.player.Inventory()
 			if inventoryIndex >= 0 && inventoryIndex < len(inventory) {
 				stateAction := ui.stateAction
+				if stateAction.action == ActThrow {
+					ui.startTargeting(MobAction{ActThrow, ThrowTarget{Item: inventory[inventoryIndex]}}, ThrowRange)
+					return MobAction{ActNone, nil}, GamePlayerTurn
+				}
 				stateAction.target = inventory[inventoryIndex]
 				ui.setState(StateGame, MobAction{ActNone, nil})
 				return stateAction, GameWorldTurn
@@ -219,17 +322,176 @@ func (ui *termboxUI) HandleKey(char rune, key termbox.Key) (MobAction, GameState
 			ui.setState(StateGame, MobAction{ActNone, nil})
 			return MobAction{ActNone, nil}, ui.game.state
 		}
+	case StateEquipment:
+		if char != 0 {
+			slot := int(char - 'a')
+			if slot >= 0 && slot < len(ui.game.player.WearSlots()) {
+				stateAction := ui.stateAction
+				stateAction.target = uint(slot)
+				ui.setState(StateGame, MobAction{ActNone, nil})
+				return stateAction, GameWorldTurn
+			}
+			return MobAction{ActNone, nil}, ui.game.state
+		}
+		switch key {
+		case termbox.KeyEsc:
+			ui.setState(StateGame, MobAction{ActNone, nil})
+			return MobAction{ActNone, nil}, ui.game.state
+		}
+	case StateTargeting:
+		switch char {
+		case 'h', 'j', 'k', 'l', 'y', 'u', 'b', 'n':
+			ui.moveCursor(ui.HandleMovementKey(char, key).target.(Vector))
+			return MobAction{ActNone, nil}, ui.game.state
+		// Next and previous target
+		case '+', '=':
+			ui.cycleTarget(1)
+			return MobAction{ActNone, nil}, ui.game.state
+		case '-':
+			ui.cycleTarget(-1)
+			return MobAction{ActNone, nil}, ui.game.state
+		// Confirm
+		case 'f', 't', '.':
+			return ui.confirmTarget()
+		}
+		switch key {
+		case termbox.KeyArrowUp, termbox.KeyArrowRight, termbox.KeyArrowDown, termbox.KeyArrowLeft:
+			ui.moveCursor(ui.HandleMovementKey(char, key).target.(Vector))
+			return MobAction{ActNone, nil}, ui.game.state
+		case termbox.KeyTab:
+			ui.cycleTarget(1)
+			return MobAction{ActNone, nil}, ui.game.state
+		case termbox.KeyEnter:
+			return ui.confirmTarget()
+		case termbox.KeyEsc:
+			ui.setState(StateGame, MobAction{ActNone, nil})
+			return MobAction{ActNone, nil}, ui.game.state
+		}
+	case StateLook:
+		switch char {
+		case 'h', 'j', 'k', 'l', 'y', 'u', 'b', 'n':
+			ui.moveCursor(ui.HandleMovementKey(char, key).target.(Vector))
+			return MobAction{ActNone, nil}, ui.game.state
+		case 'x', ';':
+			ui.setState(StateGame, MobAction{ActNone, nil})
+			return MobAction{ActNone, nil}, ui.game.state
+		// Travel
+		case '_':
+			return ui.travelToCursor()
+		}
+		switch key {
+		case termbox.KeyArrowUp, termbox.KeyArrowRight, termbox.KeyArrowDown, termbox.KeyArrowLeft:
+			ui.moveCursor(ui.HandleMovementKey(char, key).target.(Vector))
+			return MobAction{ActNone, nil}, ui.game.state
+		case termbox.KeyEnter:
+			return ui.travelToCursor()
+		case termbox.KeyEsc:
+			ui.setState(StateGame, MobAction{ActNone, nil})
+			return MobAction{ActNone, nil}, ui.game.state
+		}
+	case StateDirection:
+		switch char {
+		case 'h', 'j', 'k', 'l', 'y', 'u', 'b', 'n':
+			return ui.chooseDirection(char, key)
+		}
+		switch key {
+		case termbox.KeyArrowUp, termbox.KeyArrowRight, termbox.KeyArrowDown, termbox.KeyArrowLeft:
+			return ui.chooseDirection(char, key)
+		case termbox.KeyEsc:
+			ui.setState(StateGame, MobAction{ActNone, nil})
+			return MobAction{ActNone, nil}, ui.game.state
+		}
 	case StateClosed:
 		ui.log.Panic("am closed, can't handle keys :(")
 	}
 	if char == 0 {
-		ui.game.AddMessage(fmt.Sprintf("Unhandled key: %s", string(key)))
+		ui.game.AddMessage(fmt.Sprintf("Unhandled key: %d", key))
 	} else {
 		ui.game.AddMessage(fmt.Sprintf("Unhandled key: %c", char))
 	}
 	return MobAction{ActNone, nil}, ui.game.state
 }
 
+// startTargeting puts the cursor on the nearest visible Mob, or the Player if
+// there isn't one, to pick a target for action out to targetRange.
+func (ui *termboxUI) startTargeting(action MobAction, targetRange int) {
+	ui.targets = ui.game.VisibleHostiles()
+	ui.targetIndex = 0
+	ui.targetRange = targetRange
+	ui.setState(StateTargeting, action)
+	cursor := ui.game.player.Loc()
+	if len(ui.targets) > 0 {
+		cursor = ui.targets[0].Loc()
+	}
+	ui.setCursor(cursor)
+}
+
+// cycleTarget moves the cursor by step through the visible Mobs
+func (ui *termboxUI) cycleTarget(step int) {
+	if len(ui.targets) == 0 {
+		return
+	}
+	ui.targetIndex = (ui.targetIndex + step + len(ui.targets)) % len(ui.targets)
+	ui.setCursor(ui.targets[ui.targetIndex].Loc())
+}
+
+func (ui *termboxUI) moveCursor(move Vector) {
+	ui.setCursor(ui.cameraWidget.cursor.Add(move))
+}
+
+// setCursor puts the cursor on loc. While targeting, it shows the path a
+// projectile would take to get there.
+func (ui *termboxUI) setCursor(loc Vector) {
+	ui.cameraWidget.cursor = loc
+	ui.cameraWidget.path = nil
+	if ui.state == StateTargeting {
+		ui.cameraWidget.path, _ = ui.game.currentDungeon.ProjectilePath(ui.game.player.Loc(), loc, ui.targetRange)
+	}
+	ui.MarkDirty()
+}
+
+// confirmTarget aims the action being targeted at the cursor
+func (ui *termboxUI) confirmTarget() (MobAction, GameState) {
+	action := ui.stateAction
+	cursor := ui.cameraWidget.cursor
+	if cursor == ui.game.player.Loc() {
+		ui.game.AddMessage("You can't target yourself")
+		return MobAction{ActNone, nil}, ui.game.state
+	}
+	switch action.action {
+	case ActFire:
+		action.target = cursor
+	case ActThrow:
+		throw := action.target.(ThrowTarget)
+		throw.At = cursor
+		action.target = throw
+	default:
+		ui.log.Panicf("Don't know how to target %s", action)
+	}
+	ui.setState(StateGame, MobAction{ActNone, nil})
+	return action, GameWorldTurn
+}
+
+// travelToCursor sets the Player travelling to the Tile under the cursor
+func (ui *termboxUI) travelToCursor() (MobAction, GameState) {
+	cursor := ui.cameraWidget.cursor
+	if cursor == ui.game.player.Loc() {
+		ui.game.AddMessage("You're already there")
+		return MobAction{ActNone, nil}, ui.game.state
+	}
+	ui.setState(StateGame, MobAction{ActNone, nil})
+	return MobAction{ActTravel, cursor}, GameWorldTurn
+}
+
+// chooseDirection aims the action waiting on a direction the way the movement
+// key points
+func (ui *termboxUI) chooseDirection(char rune, key termbox.Key) (MobAction, GameState) {
+	action := ui.stateAction
+	action.target = ui.HandleMovementKey(char, key).target
+	ui.setState(StateGame, MobAction{ActNone, nil})
+	return action, GameWorldTurn
+}
+
 // HandleMovementKey maps a key to its respective Vector, and passes it
 // to Game.Move. Returns true if the move was successful.
 func (ui *termboxUI) HandleMovementKey(char rune, key termbox.Key) MobAction {
@@ -262,7 +524,7 @@ func (ui *termboxUI) HandleMovementKey(char rune, key termbox.Key) MobAction {
 		case termbox.KeyArrowLeft:
 			movement = MoveWest
 		default:
-			ui.log.Panicf("Not a movement key: %s", string(key))
+			ui.log.Panicf("Not a movement key: %d", key)
 			return MobAction{ActNone, nil}
 		}
 	default:
@@ -283,7 +545,7 @@ func (ui *termboxUI) HandleEvent(e termbox.Event) (MobAction, GameState) {
 	case termbox.EventError:
 		ui.log.Panic(e.Err)
 	}
-	ui.log.Panicf("Unhandled event: %s", e)
+	ui.log.Panicf("Unhandled event: %v", e)
 	return MobAction{ActNone, nil}, GameInvalidState
 }
 
@@ -296,6 +558,7 @@ func (ui *termboxUI) setState(state State, stateAction MobAction) {
 	}
 	ui.state = state
 	ui.MarkDirty()
+	ui.cameraWidget.showCursor = state == StateTargeting || state == StateLook
 	switch state {
 	case StateGame:
 		ui.paintables = []Paintable{
@@ -308,6 +571,29 @@ func (ui *termboxUI) setState(state State, stateAction MobAction) {
 			ui.inventoryWidget,
 			ui.logWidget,
 		}
+	case StateEquipment:
+		ui.paintables = []Paintable{
+			ui.equipmentWidget,
+			ui.logWidget,
+		}
+	case StateTargeting:
+		ui.paintables = []Paintable{
+			ui.cameraWidget,
+			ui.logWidget,
+			ui.menuWidget,
+		}
+	case StateDirection:
+		ui.paintables = []Paintable{
+			ui.cameraWidget,
+			ui.logWidget,
+			ui.menuWidget,
+		}
+	case StateLook:
+		ui.paintables = []Paintable{
+			ui.cameraWidget,
+			ui.logWidget,
+			ui.lookWidget,
+		}
 	case StateClosed:
 		ui.paintables = []Paintable{}
 	default:
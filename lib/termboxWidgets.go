@@ -2,6 +2,7 @@ package gorl
 
 import (
 	"fmt"
+	"strings"
 
 	"github.com/nsf/termbox-go"
 )
@@ -24,18 +25,23 @@ type cameraWidget struct {
 	widget
 	dungeon *Dungeon
 	center  Vector
+	// While targeting or looking, the cursor is highlighted, along with any
+	// path to it
+	showCursor bool
+	cursor     Vector
+	path       []Vector
+	// In 256 color mode, what the Player can see is tinted by the light
+	// falling on it
+	colors256 bool
 }
 
 // Paint paints the cameraWidget to the TermboxUI
 func (camera *cameraWidget) Paint() {
 	var (
-		tile   *Tile
 		offset Vector
-		loc    Vector
 		out    Vector
+		loc    Vector
 		x, y   int
-		char   rune
-		color  termbox.Attribute
 	)
 
 	ne := camera.center.Add(Vector{-camera.widget.Width() / 2, -camera.widget.Height() / 2})
@@ -43,35 +49,45 @@ func (camera *cameraWidget) Paint() {
 	for x = 0; x < camera.widget.Width(); x++ {
 		for y = 0; y < camera.widget.Height(); y++ {
 			offset = Vector{x, y}
-			loc = ne.Add(offset)
 			out = camera.TopLeft().Add(offset)
-			tile = camera.dungeon.Tile(loc)
-			if tile.Seen() || tile.Visible() {
-				if tile.Visible() {
-					fg := camera.dungeon.FeatureGroup(loc)
-					if fg.mob != nil {
-						char = fg.mob.Char()
-						color = fg.mob.Color()
-					} else if fg.feature != nil {
-						char = fg.feature.Char()
-						color = fg.feature.Color()
-					} else if len(fg.items) > 0 {
-						char = fg.items[len(fg.items)-1].Char()
-						color = fg.items[len(fg.items)-1].Color()
-					} else {
-						char = tile.c
-						color = tile.color | termbox.AttrBold
-					}
-					camera.ui.PutRuneColor(out, char, color, termbox.ColorDefault)
-				} else {
-					camera.ui.PutRuneColor(out, tile.c, tile.color, termbox.ColorDefault)
-				}
+			loc = ne.Add(offset)
+			if char, color, seen := camera.cell(loc); seen {
+				camera.ui.PutRuneColor(out, char, color, termbox.ColorDefault)
 			}
 		}
 	}
+	if camera.showCursor {
+		for _, loc := range camera.path {
+			camera.highlight(ne, loc, termbox.ColorBlue)
+		}
+		camera.highlight(ne, camera.cursor, termbox.ColorRed)
+	}
 	camera.widget.Paint()
 }
 
+// highlight paints the cell at loc, with the camera's top left corner at ne,
+// on a bg background.
+func (camera *cameraWidget) highlight(ne, loc Vector, bg termbox.Attribute) {
+	offset := loc.Sub(ne)
+	if offset.x < 0 || offset.y < 0 || offset.x >= camera.widget.Width() || offset.y >= camera.widget.Height() {
+		return
+	}
+	char, color, _ := camera.cell(loc)
+	camera.ui.PutRuneColor(camera.TopLeft().Add(offset), char, color, bg)
+}
+
+// cell returns what the Player sees at loc, as cameraCell, in the colors the
+// camera paints it in.
+func (camera *cameraWidget) cell(loc Vector) (rune, termbox.Attribute, bool) {
+	char, color, seen := cameraCell(camera.dungeon, loc)
+	if tile := camera.dungeon.Tile(loc); tile.Visible() {
+		color = shade(tile, color, camera.colors256)
+	} else if color == RememberedColor && camera.colors256 {
+		color = remembered256
+	}
+	return char, color, seen
+}
+
 type logWidget struct {
 	widget
 	messages []string
@@ -87,16 +103,47 @@ func (lw *logWidget) Paint() {
 	lw.widget.Paint()
 }
 
-// A menuWidget in theory displays a menu.
+// A menuWidget in theory displays a menu. For now it shows the Player's
+// status.
 type menuWidget struct {
 	widget
+	game *Game
 }
 
 // Paint paints the MenuWidget to the UI
 func (mw *menuWidget) Paint() {
+	lines := []string{
+		fmt.Sprintf("Depth: %d", mw.game.currentDungeon.Depth()),
+		fmt.Sprintf("Turn: %d", mw.game.turn),
+		fmt.Sprintf("Health: %d/%d", mw.game.player.Health(), mw.game.player.MaxHealth()),
+		fmt.Sprintf("Defense: %d", mw.game.player.Defense()),
+	}
+	for _, effect := range mw.game.player.Effects() {
+		adjective := effect.Kind.Adjective()
+		lines = append(lines, fmt.Sprintf("%s%s (%d)", strings.ToUpper(adjective[:1]), adjective[1:], effect.Turns))
+	}
+	lines = append(lines, "", fmt.Sprintf("Seed: %d", mw.game.Seed()))
+	for i, line := range lines {
+		mw.ui.PrintAt(mw.TopLeft().Add(Vector{1, 1 + i}), line)
+	}
 	mw.widget.Paint()
 }
 
+// A lookWidget describes whatever is under the camera's cursor
+type lookWidget struct {
+	widget
+	camera *cameraWidget
+}
+
+// Paint paints the lookWidget to the UI
+func (lw *lookWidget) Paint() {
+	lw.ui.PrintAt(lw.TopLeft().Add(Vector{1, 1}), "Look")
+	for i, line := range describeCell(lw.camera.dungeon, lw.camera.cursor) {
+		lw.ui.PrintAt(lw.TopLeft().Add(Vector{1, 3 + i}), line)
+	}
+	lw.widget.Paint()
+}
+
 type inventoryWidget struct {
 	widget
 	owner Mob
@@ -118,3 +165,25 @@ func (iw *inventoryWidget) Paint() {
 	}
 	iw.widget.Paint()
 }
+
+type equipmentWidget struct {
+	widget
+	owner Mob
+}
+
+func (ew *equipmentWidget) Paint() {
+	ew.ui.PrintAt(
+		ew.TopLeft().Add(Vector{1, 1}),
+		"Equipment",
+	)
+	wearing := ew.owner.Wearing()
+	for i, slot := range ew.owner.WearSlots() {
+		name := "-"
+		if wearing[i] != nil {
+			name = fmt.Sprintf("%s (%d)", wearing[i].Name(), wearing[i].Defense())
+		}
+		loc := ew.TopLeft().Add(Vector{1, 3 + i})
+		ew.ui.PrintAt(loc, fmt.Sprintf("%c) %s: %s", 'a'+i, slot, name))
+	}
+	ew.widget.Paint()
+}
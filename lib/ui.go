@@ -1,6 +1,10 @@
 package gorl
 
-import "fmt"
+import (
+	"fmt"
+
+	"github.com/nsf/termbox-go"
+)
 
 // Paintable is anything that can be painted
 type Paintable interface {
@@ -17,6 +21,16 @@ const (
 	StateGame
 	// StateInventory displays the inventory view
 	StateInventory
+	// StateEquipment displays the armor the Player is wearing
+	StateEquipment
+	// StateTargeting shows the map with a cursor, to pick what to fire or
+	// throw at
+	StateTargeting
+	// StateLook shows the map with a cursor, describing whatever is under it
+	StateLook
+	// StateDirection waits for a direction to do something in, like opening
+	// a door
+	StateDirection
 	// StateClosed is a closed UI. Entering this state is a signal to shut the game down cleanly.
 	StateClosed
 )
@@ -31,11 +45,22 @@ func (state State) String() string {
 		return "StateClosed"
 	case StateInventory:
 		return "StateInventory"
+	case StateEquipment:
+		return "StateEquipment"
+	case StateTargeting:
+		return "StateTargeting"
+	case StateLook:
+		return "StateLook"
+	case StateDirection:
+		return "StateDirection"
 	default:
 		return fmt.Sprintf("State(%d)", state)
 	}
 }
 
+// A UIFactory creates the UI for a Game
+type UIFactory func(*Game) (UI, error)
+
 type UI interface {
 	Close()
 	Paintables() []Paintable
@@ -51,6 +76,70 @@ type UI interface {
 	SetMessages([]string)
 }
 
+// cameraCell returns what the Player sees at loc in d: the top Feature there
+// if it's visible, or what they remember there if it's only been seen before,
+// which is all one color. Returns false if the Player has never seen loc.
+func cameraCell(d *Dungeon, loc Vector) (rune, termbox.Attribute, bool) {
+	tile := d.Tile(loc)
+	if !tile.Seen() && !tile.Visible() {
+		return ' ', termbox.ColorDefault, false
+	}
+	if !tile.Visible() {
+		if memory, ok := d.Memory(loc); ok {
+			return memory.Char, RememberedColor, true
+		}
+		return tile.c, RememberedColor, true
+	}
+	fg := d.featuresAt(loc)
+	if fg.mob != nil {
+		return fg.mob.Char(), fg.mob.Color(), true
+	} else if fg.feature != nil {
+		return fg.feature.Char(), fg.feature.Color(), true
+	} else if len(fg.items) > 0 {
+		top := fg.items[len(fg.items)-1]
+		return top.Char(), top.Color(), true
+	}
+	return tile.c, tile.color | termbox.AttrBold, true
+}
+
+// describeCell returns what the Player knows about loc in d, one thing per
+// line and topmost first. Tiles the Player has only seen before are described
+// as they remember them, which may not be how they are now.
+func describeCell(d *Dungeon, loc Vector) []string {
+	tile := d.Tile(loc)
+	if !tile.Seen() && !tile.Visible() {
+		return []string{"unexplored"}
+	}
+	if !tile.Visible() {
+		var lines []string
+		if memory, ok := d.Memory(loc); ok {
+			lines = append(lines, memory.Name)
+		}
+		return append(lines, fmt.Sprintf("%s (remembered)", tile.Description()))
+	}
+	var lines []string
+	for _, f := range d.featuresAt(loc).Each() {
+		lines = append(lines, describeFeature(f))
+	}
+	return append(lines, tile.Description())
+}
+
+// describeFeature names f, with a Mob's health and effects
+func describeFeature(f Feature) string {
+	switch f := f.(type) {
+	case *player:
+		return "you"
+	case Mob:
+		description := fmt.Sprintf("%s (%d/%d)", f.Name(), f.Health(), f.MaxHealth())
+		for _, effect := range f.Effects() {
+			description += ", " + effect.Kind.Adjective()
+		}
+		return description
+	default:
+		return f.Name()
+	}
+}
+
 // A Widget represents a rectangular box in a fixed position in the UI.
 type Widget interface {
 	RectangleI
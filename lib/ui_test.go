@@ -0,0 +1,111 @@
+package gorl
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/nsf/termbox-go"
+)
+
+func TestDescribeCell(t *testing.T) {
+	game := newTestGame(t, NewScriptedActions(),
+		"#######",
+		"#@.o..#",
+		"#######",
+	)
+	d := game.currentDungeon
+	orc := d.MobAt(Vector{3, 1})
+	orc.AddEffect(Effect{EffectPoison, 3, 1})
+	d.SetFlag(FlagVisible | FlagSeen)
+	rock := NewItem("rock", '*', 1)
+	rock.SetLoc(Vector{2, 1})
+	d.AddItem(rock)
+
+	tests := []struct {
+		loc  Vector
+		want string
+	}{
+		{Vector{1, 1}, "you, floor"},
+		{Vector{2, 1}, "rock, floor"},
+		{Vector{3, 1}, "orc (10/10), poisoned, floor"},
+		{Vector{0, 0}, "wall"},
+	}
+	for _, test := range tests {
+		if got := strings.Join(describeCell(d, test.loc), ", "); got != test.want {
+			t.Errorf("describeCell(%s) = %q, want %q", test.loc, got, test.want)
+		}
+	}
+
+	// Once it's out of sight, the Player only remembers the floor
+	d.ResetFlag(FlagVisible)
+	if got := strings.Join(describeCell(d, Vector{3, 1}), ", "); got != "floor (remembered)" {
+		t.Errorf("describeCell of a remembered tile = %q", got)
+	}
+	d.ResetFlag(FlagSeen)
+	if got := strings.Join(describeCell(d, Vector{3, 1}), ", "); got != "unexplored" {
+		t.Errorf("describeCell of an unseen tile = %q", got)
+	}
+}
+
+func TestCameraCellMemory(t *testing.T) {
+	game := newTestGame(t, NewScriptedActions(),
+		"#######",
+		"#@....#",
+		"#######",
+	)
+	d := game.currentDungeon
+	gem := NewItem("gem", '*', 1)
+	gem.SetColor(termbox.ColorGreen | termbox.AttrBold)
+	gem.SetLoc(Vector{2, 1})
+	d.AddItem(gem)
+	game.updatePlayerFOV()
+
+	if char, color, _ := cameraCell(d, gem.Loc()); char != '*' || color != termbox.ColorGreen|termbox.AttrBold {
+		t.Errorf("Visible gem drawn as %c in %v", char, color)
+	}
+
+	// Somebody else takes the gem while the Player is blind
+	game.Player().AddEffect(Effect{EffectBlind, 5, 0})
+	game.updatePlayerFOV()
+	d.DeleteItem(gem)
+	if char, color, _ := cameraCell(d, gem.Loc()); char != '*' || color != RememberedColor {
+		t.Errorf("Remembered gem drawn as %c in %v, want a grey *", char, color)
+	}
+	camera := &cameraWidget{dungeon: d, colors256: true}
+	if _, color, _ := camera.cell(gem.Loc()); color != remembered256 {
+		t.Errorf("Remembered gem painted in %v in 256 colors, want %v", color, remembered256)
+	}
+	if got := strings.Join(describeCell(d, gem.Loc()), ", "); got != "gem, floor (remembered)" {
+		t.Errorf("describeCell of the remembered gem = %q", got)
+	}
+
+	// Seeing the empty floor again forgets the gem
+	for game.Player().HasEffect(EffectBlind) {
+		game.Player().TickEffects()
+	}
+	game.updatePlayerFOV()
+	if _, ok := d.Memory(gem.Loc()); ok {
+		t.Error("Player still remembers the gem after seeing it's gone")
+	}
+}
+
+func TestHas256Colors(t *testing.T) {
+	tests := []struct {
+		term, colorterm string
+		want            bool
+	}{
+		{"xterm-256color", "", true},
+		{"xterm-direct", "", true},
+		{"xterm", "truecolor", true},
+		{"screen", "24bit", true},
+		{"xterm", "", false},
+		{"linux", "", false},
+		{"", "", false},
+	}
+	for _, test := range tests {
+		env := map[string]string{"TERM": test.term, "COLORTERM": test.colorterm}
+		if got := has256Colors(func(name string) string { return env[name] }); got != test.want {
+			t.Errorf("has256Colors with TERM=%q COLORTERM=%q = %t, want %t", test.term, test.colorterm, got, test.want)
+		}
+	}
+}
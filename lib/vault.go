@@ -0,0 +1,495 @@
+package gorl
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"log"
+	"math/rand"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/nsf/termbox-go"
+)
+
+// VaultsDir is the directory in a content directory that holds vault files
+const VaultsDir = "vaults"
+
+type vaultCellKind int
+
+const (
+	vaultNothing vaultCellKind = iota
+	vaultFloor
+	vaultWall
+	vaultDoor
+	vaultLockedDoor
+	vaultItem
+	vaultMonster
+	vaultFeature
+	// The rest are only for level maps
+	vaultOpenDoor
+	vaultUpStairs
+	vaultDownStairs
+	vaultStart
+	vaultKey
+)
+
+// vaultCellKinds are the kinds of thing a vault's legend can name, and
+// whether they're followed by an item id, monster id or feature name.
+var vaultCellKinds = map[string]struct {
+	kind  vaultCellKind
+	named bool
+}{
+	"floor":       {vaultFloor, false},
+	"wall":        {vaultWall, false},
+	"door":        {vaultDoor, false},
+	"locked door": {vaultLockedDoor, false},
+	"item":        {vaultItem, true},
+	"monster":     {vaultMonster, true},
+	"feature":     {vaultFeature, true},
+	"open door":   {vaultOpenDoor, false},
+	"stairs up":   {vaultUpStairs, false},
+	"stairs down": {vaultDownStairs, false},
+	"start":       {vaultStart, false},
+	"key":         {vaultKey, false},
+}
+
+// A vaultCell is what a character in a vault stands for
+type vaultCell struct {
+	kind vaultCellKind
+	id   string
+}
+
+// String returns the cell as it's written in a legend
+func (c vaultCell) String() string {
+	for word, kind := range vaultCellKinds {
+		if kind.kind != c.kind {
+			continue
+		}
+		if kind.named {
+			return word + " " + c.id
+		}
+		return word
+	}
+	return ""
+}
+
+// passable returns true if the cell can be walked into
+func (c vaultCell) passable() bool {
+	return c.kind != vaultNothing && c.kind != vaultWall && c.kind != vaultFeature
+}
+
+// defaultVaultLegend is what the characters in a vault stand for unless its
+// legend says otherwise. Spaces are left as they are, so vaults needn't be
+// rectangular.
+var defaultVaultLegend = map[rune]vaultCell{
+	' ': {vaultNothing, ""},
+	'#': {vaultWall, ""},
+	'.': {vaultFloor, ""},
+	'+': {vaultDoor, ""},
+}
+
+// A Vault is a hand designed room, drawn as a grid of characters, each of
+// which stands for a Tile, Feature, Item or monster.
+type Vault struct {
+	Name   string
+	grid   [][]rune
+	legend map[rune]vaultCell
+}
+
+// ParseVault reads the vault called name from r, checking that the items and
+// monsters in it are in content. A vault file starts with its legend, a line
+// like "o: monster orc" for each character that isn't one of the defaults,
+// then a blank line, then the grid. Legend lines starting with ';' are
+// comments. Characters stand for:
+//
+//	floor, wall, door, locked door
+//	item <item id>, monster <monster id>, feature <name>
+//
+// where a feature is drawn as its character and can't be walked through. By
+// default '#' is wall, '.' is floor, '+' is a door and ' ' is left alone.
+func ParseVault(name string, r io.Reader, content *Content) (*Vault, error) {
+	v, err := parseGrid(name, r, content, defaultVaultLegend)
+	if err != nil {
+		return nil, err
+	}
+	for c, cell := range v.legend {
+		if cell.kind >= vaultOpenDoor {
+			return nil, fmt.Errorf("%q: only level maps can have doors left open, stairs, keys or a start", c)
+		}
+	}
+	return v, nil
+}
+
+// parseGrid reads a legend and grid like a vault file's, with legend to start
+// with.
+func parseGrid(name string, r io.Reader, content *Content, legend map[rune]vaultCell) (*Vault, error) {
+	v := &Vault{Name: name, legend: make(map[rune]vaultCell)}
+	for c, cell := range legend {
+		v.legend[c] = cell
+	}
+	var lines []string
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		lines = append(lines, strings.TrimRight(scanner.Text(), "\r"))
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	// Without a blank line, there's no legend
+	gridStart := 0
+	for i, line := range lines {
+		if strings.TrimSpace(line) == "" {
+			gridStart = i + 1
+			break
+		}
+	}
+	for i, line := range lines[:gridStart] {
+		if strings.HasPrefix(line, ";") || strings.TrimSpace(line) == "" {
+			continue
+		}
+		if err := v.parseLegend(line, content); err != nil {
+			return nil, fmt.Errorf("line %d: %s", i+1, err)
+		}
+	}
+
+	width := 0
+	for _, line := range lines[gridStart:] {
+		if n := utf8.RuneCountInString(line); n > width {
+			width = n
+		}
+	}
+	passable := false
+	for i, line := range lines[gridStart:] {
+		row := []rune(line)
+		for _, c := range row {
+			cell, ok := v.legend[c]
+			if !ok {
+				return nil, fmt.Errorf("line %d: %q isn't in the legend", gridStart+i+1, c)
+			}
+			passable = passable || cell.passable()
+		}
+		for len(row) < width {
+			row = append(row, ' ')
+		}
+		v.grid = append(v.grid, row)
+	}
+	// Trailing blank lines aren't part of the vault
+	for len(v.grid) > 0 && strings.TrimSpace(string(v.grid[len(v.grid)-1])) == "" {
+		v.grid = v.grid[:len(v.grid)-1]
+	}
+	if len(v.grid) == 0 {
+		return nil, fmt.Errorf("no grid")
+	}
+	if !passable {
+		return nil, fmt.Errorf("nowhere in the vault can be walked into")
+	}
+	return v, nil
+}
+
+// parseLegend parses a legend line like "o: monster orc"
+func (v *Vault) parseLegend(line string, content *Content) error {
+	c, size := utf8.DecodeRuneInString(line)
+	if !strings.HasPrefix(line[size:], ":") {
+		return fmt.Errorf("legend %q isn't like \"o: monster orc\"", line)
+	}
+	if c == ' ' {
+		return fmt.Errorf("' ' is always left alone")
+	}
+	description := strings.TrimSpace(line[size+1:])
+	for word, kind := range vaultCellKinds {
+		if !kind.named && description == word {
+			v.legend[c] = vaultCell{kind.kind, ""}
+			return nil
+		}
+		if !kind.named || !strings.HasPrefix(description, word+" ") {
+			continue
+		}
+		id := strings.TrimSpace(strings.TrimPrefix(description, word))
+		switch {
+		case kind.kind == vaultItem && (content == nil || content.Items[id] == nil):
+			return fmt.Errorf("unknown item %q", id)
+		case kind.kind == vaultMonster && (content == nil || content.Monsters[id] == nil):
+			return fmt.Errorf("unknown monster %q", id)
+		}
+		v.legend[c] = vaultCell{kind.kind, id}
+		return nil
+	}
+	return fmt.Errorf("%q isn't floor, wall, door, open door, locked door, stairs up, stairs down, start, key, item, monster or feature", description)
+}
+
+func (v *Vault) Width() int {
+	return len(v.grid[0])
+}
+
+func (v *Vault) Height() int {
+	return len(v.grid)
+}
+
+// oriented returns a copy of the Vault turned clockwise by quarter turns, and
+// then mirrored left to right if mirrored is true.
+func (v *Vault) oriented(turns int, mirrored bool) *Vault {
+	grid := v.grid
+	for i := 0; i < turns%4; i++ {
+		turned := make([][]rune, len(grid[0]))
+		for y := range turned {
+			turned[y] = make([]rune, len(grid))
+			for x := range turned[y] {
+				turned[y][x] = grid[len(grid)-1-x][y]
+			}
+		}
+		grid = turned
+	}
+	if mirrored {
+		flipped := make([][]rune, len(grid))
+		for y, row := range grid {
+			flipped[y] = make([]rune, len(row))
+			for x, c := range row {
+				flipped[y][len(row)-1-x] = c
+			}
+		}
+		grid = flipped
+	}
+	return &Vault{v.Name, grid, v.legend}
+}
+
+// cell returns what's at loc in the Vault, or nothing outside it
+func (v *Vault) cell(loc Vector) vaultCell {
+	if loc.x < 0 || loc.y < 0 || loc.x >= v.Width() || loc.y >= v.Height() {
+		return vaultCell{vaultNothing, ""}
+	}
+	return v.legend[v.grid[loc.y][loc.x]]
+}
+
+// guarded returns true if there are monsters in the Vault
+func (v *Vault) guarded() bool {
+	for _, cell := range v.legend {
+		if cell.kind == vaultMonster {
+			return true
+		}
+	}
+	return false
+}
+
+// vaultFits returns true if v can go at topLeft in d. The vault and a border
+// of a tile all round it must be inside the level, away from every other room
+// and from anything already on the level. Its walls can only land on floor
+// if the border is all floor too, so there's a way round it, or else the
+// vault could cut the level in two.
+func (d *Dungeon) vaultFits(v *Vault, topLeft Vector) bool {
+	area := Rectangle{topLeft.Sub(Vector{1, 1}), Vector{v.Width() + 2, v.Height() + 2}}
+	if area.topLeft.x < 0 || area.topLeft.y < 0 || area.BottomRight().x > d.width || area.BottomRight().y > d.height {
+		return false
+	}
+	for _, room := range d.rooms {
+		if room.Area.Overlaps(area) {
+			return false
+		}
+	}
+	if area.Contains(d.origin) {
+		return false
+	}
+	for loc := range d.features {
+		if area.Contains(loc) {
+			return false
+		}
+	}
+	wayRound := true
+	for y := area.topLeft.y; y < area.BottomRight().y; y++ {
+		for x := area.topLeft.x; x < area.BottomRight().x; x++ {
+			inside := Vector{x, y}.Sub(topLeft)
+			if inside.x < 0 || inside.y < 0 || inside.x >= v.Width() || inside.y >= v.Height() {
+				wayRound = wayRound && d.tiles[y][x].Crossable()
+			}
+		}
+	}
+	if wayRound {
+		return true
+	}
+	for y := 0; y < v.Height(); y++ {
+		for x := 0; x < v.Width(); x++ {
+			cell := v.cell(Vector{x, y})
+			if cell.kind != vaultNothing && !cell.passable() && d.Tile(topLeft.Add(Vector{x, y})).Crossable() {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// stampVault puts v on d at topLeft, making whatever's in it from content.
+// Locked doors in the vault all open with one key, left somewhere outside it
+// that can be reached without it.
+func (d *Dungeon) stampVault(v *Vault, topLeft Vector, content *Content, dice *rand.Rand) error {
+	area := Rectangle{topLeft, Vector{v.Width(), v.Height()}}
+	lockID := fmt.Sprintf("%s at depth %d", v.Name, d.depth)
+	locked := false
+	for y := 0; y < v.Height(); y++ {
+		for x := 0; x < v.Width(); x++ {
+			cell, c, loc := v.cell(Vector{x, y}), v.grid[y][x], topLeft.Add(Vector{x, y})
+			switch cell.kind {
+			case vaultNothing:
+				continue
+			case vaultWall:
+				d.tiles[loc.y][loc.x] = newWall()
+				continue
+			}
+			d.tiles[loc.y][loc.x] = newFloor()
+			switch cell.kind {
+			case vaultDoor, vaultLockedDoor:
+				state := DoorClosed
+				if cell.kind == vaultLockedDoor {
+					state, locked = DoorLocked, true
+				}
+				door := NewDoor(state, lockID)
+				door.SetLoc(loc)
+				d.AddFeature(door)
+			case vaultFeature:
+				feature := NewFeature(cell.id, c)
+				feature.SetLoc(loc)
+				d.AddFeature(feature)
+			case vaultItem:
+				item, err := content.NewItem(cell.id)
+				if err != nil {
+					return err
+				}
+				item.SetLoc(loc)
+				d.AddItem(item)
+			case vaultMonster:
+				mob, err := content.NewMonster(cell.id, d.log, d)
+				if err != nil {
+					return err
+				}
+				mob.SetLoc(loc)
+				d.AddMob(mob)
+			}
+		}
+	}
+	d.digEntrances(v, topLeft)
+	d.rooms = append(d.rooms, Room{area, v.Name})
+	if locked {
+		key := NewKey(fmt.Sprintf("key to the %s", v.Name), '-', 1, lockID)
+		key.SetColor(termbox.ColorYellow | termbox.AttrBold)
+		d.placeKey(key, area, dice)
+	}
+	return nil
+}
+
+// digEntrances digs a tunnel out from every way into the vault at topLeft to
+// the nearest floor outside it. A way in is anywhere on the edge of the vault
+// that can be walked into.
+func (d *Dungeon) digEntrances(v *Vault, topLeft Vector) {
+	area := Rectangle{topLeft, Vector{v.Width(), v.Height()}}
+	for y := 0; y < v.Height(); y++ {
+		for x := 0; x < v.Width(); x++ {
+			if !v.cell(Vector{x, y}).passable() {
+				continue
+			}
+			for _, direction := range walkDirections {
+				if v.cell(Vector{x, y}.Add(direction)).kind != vaultNothing {
+					continue
+				}
+				outside := topLeft.Add(Vector{x, y}).Add(direction)
+				if !area.Contains(outside) {
+					d.digTunnel(outside, area)
+				}
+			}
+		}
+	}
+}
+
+// digTunnel digs the shortest way from start to the nearest floor, without
+// going through area or the edge of the level.
+func (d *Dungeon) digTunnel(start Vector, area Rectangle) {
+	if onEdge(start.x, start.y, d.width, d.height) {
+		return
+	}
+	from := map[Vector]Vector{start: start}
+	queue := []Vector{start}
+	for len(queue) > 0 {
+		loc := queue[0]
+		queue = queue[1:]
+		if d.Tile(loc).Crossable() {
+			for ; loc != start; loc = from[loc] {
+				d.tiles[loc.y][loc.x] = newFloor()
+			}
+			d.tiles[start.y][start.x] = newFloor()
+			return
+		}
+		for _, direction := range walkDirections {
+			next := loc.Add(direction)
+			if _, seen := from[next]; seen || area.Contains(next) || onEdge(next.x, next.y, d.width, d.height) ||
+				next.x < 0 || next.y < 0 || next.x >= d.width || next.y >= d.height {
+				continue
+			}
+			from[next] = loc
+			queue = append(queue, next)
+		}
+	}
+}
+
+// MaxVaults is the most vaults a VaultGenerator puts on any one level
+const MaxVaults = 2
+
+// VaultTries is how many places a VaultGenerator tries putting each vault
+// before giving up on it
+const VaultTries = 50
+
+// A VaultGenerator adds vaults from Content to the levels another Generator
+// makes, turned and mirrored at random, wherever they fit.
+type VaultGenerator struct {
+	Generator Generator
+	Content   *Content
+}
+
+// WithVaults returns generator with the vaults in content added to its levels
+func WithVaults(generator Generator, content *Content) Generator {
+	if content == nil || len(content.Vaults) == 0 {
+		return generator
+	}
+	return VaultGenerator{generator, content}
+}
+
+// MinLevelSize is the MinLevelSize of the Generator the vaults are added to
+func (g VaultGenerator) MinLevelSize() (width, height int) {
+	return MinLevelSize(g.Generator)
+}
+
+func (g VaultGenerator) Generate(log *log.Logger, dice *rand.Rand, width, height, depth int) *Dungeon {
+	d := g.Generator.Generate(log, dice, width, height, depth)
+	for i := 0; i < MaxVaults; i++ {
+		vault := g.Content.Vaults[dice.Intn(len(g.Content.Vaults))]
+		vault = vault.oriented(dice.Intn(4), dice.Intn(2) == 0)
+		if vault.Width()+2 > width || vault.Height()+2 > height {
+			continue
+		}
+		for try := 0; try < VaultTries; try++ {
+			topLeft := Vector{
+				1 + dice.Intn(width-vault.Width()-1),
+				1 + dice.Intn(height-vault.Height()-1),
+			}
+			if !d.vaultFits(vault, topLeft) {
+				continue
+			}
+			// Monsters in vaults are kept as far from where the Player
+			// arrives as any others
+			near := Rectangle{
+				topLeft.Sub(Vector{MinSpawnDistance - 1, MinSpawnDistance - 1}),
+				Vector{vault.Width() + 2*(MinSpawnDistance-1), vault.Height() + 2*(MinSpawnDistance-1)},
+			}
+			if vault.guarded() && near.Contains(d.origin) {
+				continue
+			}
+			log.Printf("Putting vault %s at %s", vault.Name, topLeft)
+			if err := d.stampVault(vault, topLeft, g.Content, dice); err != nil {
+				log.Panic(err)
+			}
+			break
+		}
+	}
+	if err := d.connect(dice); err != nil {
+		log.Panic(err)
+	}
+	return d
+}
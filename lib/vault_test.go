@@ -0,0 +1,139 @@
+package gorl
+
+import (
+	"math/rand"
+	"strings"
+	"testing"
+)
+
+const testVault = `; a closet
+=: locked door
+!: item potion of healing
+
+###
+#!#
+#=#
+`
+
+func TestParseVault(t *testing.T) {
+	content := testContent(t)
+	vault, err := ParseVault("closet", strings.NewReader(testVault), content)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if vault.Width() != 3 || vault.Height() != 3 {
+		t.Errorf("Vault is %dx%d; want 3x3", vault.Width(), vault.Height())
+	}
+	if cell := vault.cell(Vector{1, 1}); cell.kind != vaultItem || cell.id != "potion of healing" {
+		t.Errorf("cell at (1, 1) = %v; want a potion of healing", cell)
+	}
+	if cell := vault.cell(Vector{1, 2}); cell.kind != vaultLockedDoor {
+		t.Errorf("cell at (1, 2) = %v; want a locked door", cell)
+	}
+
+	tests := []struct {
+		vault, want string
+	}{
+		{"o: monster dragon\n\n.o.", `line 1: unknown monster "dragon"`},
+		{"!: item\n\n.!.", `line 1: "item" isn't floor, wall, door`},
+		{"o monster orc\n\n.o.", `line 1: legend "o monster orc" isn't like`},
+		{"\n.x.", `line 2: 'x' isn't in the legend`},
+		{"###\n###", `nowhere in the vault can be walked into`},
+		{"o: monster orc\n\n", `no grid`},
+	}
+	for _, test := range tests {
+		if _, err := ParseVault("bad", strings.NewReader(test.vault), content); err == nil || !strings.Contains(err.Error(), test.want) {
+			t.Errorf("ParseVault(%q) = %v; want an error containing %q", test.vault, err, test.want)
+		}
+	}
+}
+
+func TestVaultOriented(t *testing.T) {
+	vault, err := ParseVault("corner", strings.NewReader("##\n#.\n#."), testContent(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		turns    int
+		mirrored bool
+		want     []string
+	}{
+		{0, false, []string{"##", "#.", "#."}},
+		{1, false, []string{"###", "..#"}},
+		{2, false, []string{".#", ".#", "##"}},
+		{3, false, []string{"#..", "###"}},
+		{0, true, []string{"##", ".#", ".#"}},
+		{1, true, []string{"###", "#.."}},
+	}
+	for _, test := range tests {
+		oriented := vault.oriented(test.turns, test.mirrored)
+		var got []string
+		for _, row := range oriented.grid {
+			got = append(got, string(row))
+		}
+		if strings.Join(got, "\n") != strings.Join(test.want, "\n") {
+			t.Errorf("oriented(%d, %t) = %q; want %q", test.turns, test.mirrored, got, test.want)
+		}
+	}
+}
+
+func TestStampVault(t *testing.T) {
+	content := testContent(t)
+	vault, err := ParseVault("closet", strings.NewReader(testVault), content)
+	if err != nil {
+		t.Fatal(err)
+	}
+	d := newTestDungeon(
+		"##########",
+		"#..#######",
+		"#..#######",
+		"##########",
+		"##########",
+		"##########",
+		"##########",
+	)
+	d.origin = Vector{1, 1}
+	d.rooms = append(d.rooms, Room{Rectangle{Vector{0, 0}, Vector{4, 4}}, ""})
+	if d.vaultFits(vault, Vector{3, 1}) {
+		t.Error("Vault fits on top of a room")
+	}
+	if d.vaultFits(vault, Vector{7, 1}) {
+		t.Error("Vault fits on the edge of the level")
+	}
+	d.tiles[2][5] = newFloor()
+	if d.vaultFits(vault, Vector{5, 1}) {
+		t.Error("Vault fits with a wall on floor")
+	}
+	d.tiles[2][5] = newWall()
+	if !d.vaultFits(vault, Vector{5, 1}) {
+		t.Fatal("Vault doesn't fit in solid rock")
+	}
+
+	if err := d.stampVault(vault, Vector{5, 1}, content, rand.New(rand.NewSource(1))); err != nil {
+		t.Fatal(err)
+	}
+	if door, ok := d.FeatureAt(Vector{6, 3}).(Door); !ok || door.State() != DoorLocked {
+		t.Errorf("No locked door at (6, 3): %v", d.FeatureAt(Vector{6, 3}))
+	}
+	if items := d.ItemsAt(Vector{6, 2}); len(items) != 1 || items[0].Name() != "potion of healing" {
+		t.Errorf("Items in the closet are %v; want a potion of healing", items)
+	}
+	// A tunnel is dug from the door to the room
+	if path, ok := d.FindPath(Vector{6, 4}, Vector{1, 1}, PathOptions{}); !ok {
+		t.Errorf("No way from the vault to the room: %v", path)
+	}
+	keys := 0
+	for _, loc := range d.featureLocs() {
+		for _, item := range d.ItemsAt(loc) {
+			if key, ok := item.(Key); ok && key.Fits(d.FeatureAt(Vector{6, 3}).(Door)) {
+				keys++
+			}
+		}
+	}
+	if keys != 1 {
+		t.Errorf("%d keys to the closet; want 1", keys)
+	}
+	if rooms := d.Rooms(); len(rooms) != 2 || rooms[1].Vault != "closet" {
+		t.Errorf("Rooms() = %v; want the room and the closet", rooms)
+	}
+}
@@ -52,3 +52,21 @@ func (v Vector) Unit() Vector {
 	}
 	return v
 }
+
+// vectorsByRow sorts Vectors top to bottom, then left to right
+type vectorsByRow []Vector
+
+func (v vectorsByRow) Len() int {
+	return len(v)
+}
+
+func (v vectorsByRow) Less(i, j int) bool {
+	if v[i].y == v[j].y {
+		return v[i].x < v[j].x
+	}
+	return v[i].y < v[j].y
+}
+
+func (v vectorsByRow) Swap(i, j int) {
+	v[i], v[j] = v[j], v[i]
+}
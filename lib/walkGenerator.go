@@ -0,0 +1,61 @@
+package gorl
+
+import (
+	"log"
+	"math/rand"
+)
+
+// A WalkGenerator makes levels with a drunkard's walk: starting in the
+// middle of solid rock, it digs out a tunnel one random step at a time until
+// enough of the level is dug out.
+type WalkGenerator struct {
+	// Coverage is the fraction of the level to dig out
+	Coverage float64
+}
+
+// DefaultWalkGenerator digs out a bit over a third of each level
+var DefaultWalkGenerator = WalkGenerator{Coverage: 0.35}
+
+// walkDirections are the steps a WalkGenerator can take. Going straight
+// rather than diagonally leaves tunnels wide enough to walk down.
+var walkDirections = []Vector{MoveNorth, MoveEast, MoveSouth, MoveWest}
+
+func (g WalkGenerator) Generate(log *log.Logger, dice *rand.Rand, width, height, depth int) *Dungeon {
+	if g.Coverage <= 0 || g.Coverage > 1 {
+		log.Panicf("Bad walk generator: %+v", g)
+	}
+	if width < 3 || height < 3 {
+		log.Panicf("Level is too small to dig out: %dx%d", width, height)
+	}
+	d := newSolidDungeon(log, width, height, depth)
+	want := int(g.Coverage * float64((width-2)*(height-2)))
+	loc := d.origin
+	d.tiles[loc.y][loc.x] = newFloor()
+	for dug := 1; dug < want; {
+		next := loc.Add(walkDirections[dice.Intn(len(walkDirections))])
+		// Keep the edge of the level solid
+		if onEdge(next.x, next.y, width, height) {
+			continue
+		}
+		loc = next
+		if !d.tiles[loc.y][loc.x].Crossable() {
+			d.tiles[loc.y][loc.x] = newFloor()
+			dug++
+		}
+	}
+	if err := d.connect(dice); err != nil {
+		log.Panic(err)
+	}
+	d.placeStairs(dice)
+	return d
+}
+
+// MinLevelSize leaves room to dig out at least two tiles, so that the stairs
+// down aren't where the Player arrives
+func (g WalkGenerator) MinLevelSize() (width, height int) {
+	size := 3
+	for g.Coverage > 0 && int(g.Coverage*float64((size-2)*(size-2))) < 2 {
+		size++
+	}
+	return size, size
+}
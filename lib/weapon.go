@@ -2,25 +2,88 @@ package gorl
 
 type Weapon interface {
 	Wieldable
+	AddOnHit(Effect)
+	SetRange(int)
+}
+
+func init() {
+	RegisterFeatureType("weapon", &weapon{}, weaponRecord{},
+		func(f Feature, s *Saver) (interface{}, error) {
+			w := f.(*weapon)
+			return weaponRecord{w.item.record(), newDamageRollRecords(w.damage), w.accuracy, w.onHit, w.rangeMax}, nil
+		},
+		func(record interface{}, l *Loader) (Feature, error) {
+			r := record.(weaponRecord)
+			w := &weapon{}
+			w.item.restore(r.Item)
+			var err error
+			w.damage, err = damageRolls(r.Damage)
+			w.accuracy = r.Accuracy
+			w.onHit = r.OnHit
+			w.rangeMax = r.Range
+			return w, err
+		},
+	)
 }
 
 type weapon struct {
 	item
-	attackStrength uint
+	damage   []DamageRoll
+	accuracy int
+	onHit    []Effect
+	rangeMax int
 }
 
-func NewWeapon(name string, char rune, weight int, attackStrength uint) Weapon {
+// NewWeapon creates and returns a weapon that rolls each of damage when it
+// hits, and adds accuracy to its wielder's.
+func NewWeapon(name string, char rune, weight int, damage []DamageRoll, accuracy int) Weapon {
 	w := weapon{
 		item{
 			*NewFeature(name, char).(*feature),
 			weight,
 		},
-		attackStrength,
+		damage,
+		accuracy,
+		nil,
+		0,
 	}
 	w.flags |= FlagCrossable
 	return &w
 }
 
-func (w *weapon) AttackStrength() uint {
-	return w.attackStrength
+func (w *weapon) Damage() []DamageRoll {
+	return w.damage
+}
+
+func (w *weapon) Accuracy() int {
+	return w.accuracy
+}
+
+// OnHit is the effects the weapon puts on anything it damages
+func (w *weapon) OnHit() []Effect {
+	return w.onHit
+}
+
+// AddOnHit makes the weapon put effect on anything it damages
+func (w *weapon) AddOnHit(effect Effect) {
+	w.onHit = append(w.onHit, effect)
+}
+
+// Range is how far the weapon can be fired, or 0 if it's only good up close
+func (w *weapon) Range() int {
+	return w.rangeMax
+}
+
+// SetRange makes the weapon fire up to max moves away
+func (w *weapon) SetRange(max int) {
+	w.rangeMax = max
+}
+
+// weaponRecord is the saved form of a weapon
+type weaponRecord struct {
+	Item     itemRecord
+	Damage   []damageRollRecord
+	Accuracy int
+	OnHit    []Effect
+	Range    int
 }